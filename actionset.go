@@ -0,0 +1,242 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ActionSet represents a set of actions, unique by name, which can be safely modified at
+// runtime, for example when hot-reloading a domain, while other goroutines plan with it.
+type ActionSet struct {
+	lock    sync.RWMutex
+	actions []Action       // The actions, in insertion order
+	index   map[string]int // The index of every action by name
+	version uint64         // The version of the set, incremented on every change
+}
+
+// NewActionSet creates a new set of actions. It fails if any of the actions is invalid.
+func NewActionSet(actions ...Action) (*ActionSet, error) {
+	set := &ActionSet{
+		actions: make([]Action, 0, len(actions)),
+		index:   make(map[string]int, len(actions)),
+	}
+
+	if err := set.Add(actions...); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Add adds the actions to the set, replacing the existing actions with the same name. The
+// actions are eagerly simulated against an empty state, so that invalid rules are reported
+// here rather than during planning. Every action must be named by a String method, since
+// actions are unique by name.
+func (s *ActionSet) Add(actions ...Action) error {
+	var errs []error
+	for _, action := range actions {
+		if _, ok := action.(fmt.Stringer); !ok && action != nil {
+			errs = append(errs, &ActionError{Action: nameOf(action), Err: fmt.Errorf("plan: action '%s' has no name", nameOf(action))})
+			continue
+		}
+
+		if err := check(action); err != nil {
+			errs = append(errs, &ActionError{Action: nameOf(action), Err: fmt.Errorf("plan: invalid action '%s': %w", nameOf(action), err)})
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Check the capacity first, so that either all of the actions are added or none
+	added := make(map[string]struct{}, len(actions))
+	for _, action := range actions {
+		name := nameOf(action)
+		if _, ok := s.index[name]; ok {
+			continue
+		}
+
+		added[name] = struct{}{}
+		if len(s.actions)+len(added) > MaxActions {
+			return &ActionError{Action: name, Err: fmt.Errorf("%w, cannot add '%s'", ErrTooManyActions, name)}
+		}
+	}
+
+	if len(actions) > 0 {
+		s.version++
+	}
+
+	for _, action := range actions {
+		name := nameOf(action)
+		if i, ok := s.index[name]; ok {
+			s.actions[i] = action
+			continue
+		}
+
+		s.index[name] = len(s.actions)
+		s.actions = append(s.actions, action)
+	}
+	return nil
+}
+
+// Define defines a new action from the rule strings of its requirements and outcomes and
+// adds it to the set, replacing any existing action with the same name.
+func (s *ActionSet) Define(name string, cost float32, require, outcome []string) error {
+	r, err := TryStateOf(require...)
+	if err != nil {
+		return &ActionError{Action: name, Err: fmt.Errorf("plan: invalid action '%s': %w", name, err)}
+	}
+
+	o, err := TryStateOf(outcome...)
+	if err != nil {
+		return &ActionError{Action: name, Err: fmt.Errorf("plan: invalid action '%s': %w", name, err)}
+	}
+
+	return s.Add(&definedAction{name: name, cost: cost, require: r, outcome: o})
+}
+
+// Remove removes the action with the name from the set and returns whether it was found.
+func (s *ActionSet) Remove(name string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	i, ok := s.index[name]
+	if !ok {
+		return false
+	}
+
+	// Keep the insertion order of the remaining actions
+	s.version++
+	delete(s.index, name)
+	s.actions = append(s.actions[:i:i], s.actions[i+1:]...)
+	for j := i; j < len(s.actions); j++ {
+		s.index[nameOf(s.actions[j])] = j
+	}
+	return true
+}
+
+// Get returns the action with the name, if any.
+func (s *ActionSet) Get(name string) (Action, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if i, ok := s.index[name]; ok {
+		return s.actions[i], true
+	}
+	return nil, false
+}
+
+// Version returns the version of the set, which is incremented every time actions are
+// added, replaced or removed. Plans and caches built from a previous version of the set
+// are stale and should be discarded.
+func (s *ActionSet) Version() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.version
+}
+
+// Len returns the number of actions in the set.
+func (s *ActionSet) Len() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.actions)
+}
+
+// Actions returns a copy of the actions of the set, which is not affected by any of the
+// subsequent changes of the set.
+func (s *ActionSet) Actions() []Action {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return append([]Action(nil), s.actions...)
+}
+
+// Planner creates a new planner for the current actions of the set.
+func (s *ActionSet) Planner(opts ...Option) (*Planner, error) {
+	return NewPlannerFrom(s, opts...)
+}
+
+// Plan finds a plan to reach the goal from the start state using the current actions
+// of the set.
+func (s *ActionSet) Plan(start, goal *State, opts ...Option) ([]Action, error) {
+	return PlanFrom(start, goal, s, opts...)
+}
+
+// NewPlannerFrom creates a new planner for the current actions of the set. The planner
+// keeps a copy of the actions, hence it needs to be created again once the version of
+// the set changes.
+func NewPlannerFrom(set *ActionSet, opts ...Option) (*Planner, error) {
+	return NewPlanner(set.Actions(), opts...)
+}
+
+// PlanFrom finds a plan to reach the goal from the start state using the current actions
+// of the set, which can't change while the search runs.
+func PlanFrom(start, goal *State, set *ActionSet, opts ...Option) ([]Action, error) {
+	set.lock.RLock()
+	defer set.lock.RUnlock()
+	return Plan(start, goal, set.actions, opts...)
+}
+
+// check simulates the action against an empty state, converting any panic, such as the
+// one of an invalid rule, into an error.
+func check(action Action) (err error) {
+	if action == nil {
+		return errors.New("plan: action is nil")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("plan: simulation failed: %v", r)
+		}
+	}()
+
+	require, outcome := describe(action, nil)
+	require.release()
+	outcome.release()
+	return nil
+}
+
+// ------------------------------------ Defined Action ------------------------------------
+
+// definedAction represents an action defined from rule strings.
+type definedAction struct {
+	name    string
+	cost    float32
+	require *State
+	outcome *State
+}
+
+// Simulate returns the requirements and outcomes of the action.
+func (a *definedAction) Simulate(_ *State) (*State, *State) {
+	return a.require, a.outcome
+}
+
+// Cost returns the cost of the action.
+func (a *definedAction) Cost() float32 {
+	return a.cost
+}
+
+// Static returns true, since the requirements and outcomes never change.
+func (a *definedAction) Static() bool {
+	return true
+}
+
+// String returns the name of the action.
+func (a *definedAction) String() string {
+	return a.name
+}
+
+// Describe returns the structured description of the action.
+func (a *definedAction) Describe() Description {
+	return Description{
+		Name:    a.name,
+		Cost:    a.cost,
+		Require: a.require.Rules(),
+		Outcome: a.outcome.Rules(),
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionSet(t *testing.T) {
+	set, err := NewActionSet(move("A->B"), move("B->C"), move("A->B", 5))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, set.Len())
+
+	// Actions with the same name replace the existing ones
+	action, ok := set.Get("A->B")
+	assert.True(t, ok)
+	assert.Equal(t, float32(5), action.Cost())
+
+	assert.NoError(t, set.Define("A->C", 200, []string{"A"}, []string{"!A", "C"}))
+	plan, err := set.Plan(StateOf("A"), StateOf("C"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->C"}, planOf(plan))
+
+	// Removing an action at runtime changes the plans
+	assert.True(t, set.Remove("B->C"))
+	assert.False(t, set.Remove("B->C"))
+	planner, err := set.Planner()
+	assert.NoError(t, err)
+
+	plan, err = planner.Plan(StateOf("A"), StateOf("C"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C"}, planOf(plan))
+
+	// Invalid rules are reported eagerly
+	assert.Error(t, set.Define("Broken", 1, []string{"A>=1"}, nil))
+	assert.Error(t, set.Add(&invalidAction{}))
+	assert.Error(t, set.Add(nil))
+	assert.Equal(t, 2, set.Len())
+
+	// The set can be given to the planner directly
+	plan, err = PlanFrom(StateOf("A"), StateOf("C"), set)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C"}, planOf(plan))
+
+	planner, err = NewPlannerFrom(set)
+	assert.NoError(t, err)
+	assert.Len(t, planner.actions, 2)
+}
+
+func TestActionSetUnnamed(t *testing.T) {
+	_, err := NewActionSet(&unnamedAction{cost: 1}, &unnamedAction{cost: 2})
+	assert.ErrorContains(t, err, "has no name")
+
+	var actionErr *ActionError
+	assert.ErrorAs(t, err, &actionErr)
+
+	set, err := NewActionSet(move("A->B"))
+	assert.NoError(t, err)
+	assert.Error(t, set.Add(&unnamedAction{cost: 1}))
+	assert.Equal(t, 1, set.Len())
+}
+
+type unnamedAction struct {
+	cost float32
+}
+
+func (a *unnamedAction) Simulate(_ *State) (*State, *State) {
+	return StateOf("A"), StateOf("B")
+}
+
+func (a *unnamedAction) Cost() float32 {
+	return a.cost
+}
+
+func TestActionSetCapacity(t *testing.T) {
+	actions := make([]Action, 0, MaxActions)
+	for i := 0; i < MaxActions-1; i++ {
+		actions = append(actions, actionOf(fmt.Sprintf("A->B%d", i), 1, StateOf("A"), StateOf("B")))
+	}
+
+	set, err := NewActionSet(actions...)
+	assert.NoError(t, err)
+	version := set.Version()
+
+	// Either all of the actions are added, or none of them
+	assert.ErrorIs(t, set.Add(move("A->X"), move("A->Y")), ErrTooManyActions)
+	assert.Equal(t, MaxActions-1, set.Len())
+	assert.Equal(t, version, set.Version())
+	_, ok := set.Get("A->X")
+	assert.False(t, ok)
+
+	// Replacing the existing actions doesn't need any capacity
+	assert.NoError(t, set.Add(move("A->X"), move("A->B0", 5), move("A->X")))
+	assert.Equal(t, MaxActions, set.Len())
+}
+
+type invalidAction struct{}
+
+func (a *invalidAction) Simulate(_ *State) (*State, *State) {
+	return StateOf("A>=1"), StateOf()
+}
+
+func (a *invalidAction) Cost() float32 {
+	return 1
+}
+
+func TestInverseOf(t *testing.T) {
+	inverse, err := InverseOf(move("A->B"), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "undo A->B", inverse.(fmt.Stringer).String())
+	assert.Equal(t, float32(2), inverse.Cost())
+
+	require, outcome := inverse.Simulate(StateOf())
+	assert.Equal(t, StateOf("!A", "B").Hash(), require.Hash())
+	assert.Equal(t, StateOf("A", "!B").Hash(), outcome.Hash())
+
+	// Walk back to A after having reached C
+	actions, err := WithInverses([]Action{move("A->B"), move("B->C")}, 1)
+	assert.NoError(t, err)
+	assert.Len(t, actions, 4)
+
+	plan, err := Plan(StateOf("!A", "!B", "C"), StateOf("A"), actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"undo B->C", "undo A->B"}, planOf(plan))
+
+	// Only assignments with a known prior value can be inversed
+	_, err = InverseOf(actionOf("Eat", 1, StateOf(), StateOf("hunger-10")), 1)
+	assert.Error(t, err)
+	_, err = InverseOf(actionOf("Half", 1, StateOf(), StateOf("hunger=50")), 1)
+	assert.Error(t, err)
+}
+
+func TestDescribeAction(t *testing.T) {
+	set, err := NewActionSet()
+	assert.NoError(t, err)
+	assert.NoError(t, set.Define("Eat", 2, []string{"food>0"}, []string{"hunger-50"}))
+
+	eat, _ := set.Get("Eat")
+	desc := DescribeAction(eat)
+	assert.Equal(t, "Eat", desc.Name)
+	assert.Equal(t, float32(2), desc.Cost)
+	assert.Equal(t, "food>0", desc.Require[0].String())
+	assert.Equal(t, "hunger-50", desc.Outcome[0].String())
+
+	// Other actions are described by simulating them
+	desc = DescribeAction(move("A->B"))
+	assert.Equal(t, "A->B", desc.Name)
+	assert.Len(t, desc.Require, 1)
+	assert.Len(t, desc.Outcome, 2)
+}
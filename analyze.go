@@ -0,0 +1,105 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Usage represents how often an action was used in the plans of an analysis. Actions
+// are told apart by their name.
+type Usage struct {
+	Action Action // The action
+	Count  int    // Number of times the action appears in the plans
+}
+
+// Analysis represents the statistics of the search space of a domain, collected by
+// planning from a number of sampled start states, which gives domain authors feedback
+// on the balance of their actions.
+type Analysis struct {
+	Samples    int       // Number of start states sampled
+	Solved     int       // Number of start states from which a plan was found
+	Expanded   uint64    // Total number of nodes expanded
+	Branching  float64   // Average number of applicable actions per expanded node
+	PlanLength float64   // Average number of actions of the plans found
+	DeadEnds   float64   // Fraction of the expanded nodes without any applicable action
+	Blockers   []Blocker // The most common preconditions blocking the actions in the dead ends
+	Usage      []Usage   // Usage of every action, from the most to the least used
+}
+
+// maxBlockers is the maximum number of blockers reported by an analysis.
+const maxBlockers = 5
+
+// Analyze plans from every one of the sampled start states to the goal and reports the
+// statistics of the search space, such as the branching factor, the average length of
+// the plans, the frequency of dead ends along with their most common blockers and the
+// usage of every action.
+func Analyze(starts []*State, goal *State, actions []Action, opts ...Option) Analysis {
+	out := Analysis{Samples: len(starts)}
+	usage := make(map[string]int, len(actions))
+	length := 0
+
+	var stats Counters
+	var dead DeadEnds
+	opts = append(opts[:len(opts):len(opts)], WithCounters(&stats), WithDeadEnds(&dead))
+	for _, start := range starts {
+		stats = Counters{}
+		plan, err := Plan(start, goal, actions, opts...)
+		out.Expanded += stats.Expand
+		out.Branching += float64(stats.Branch)
+		out.DeadEnds += float64(stats.DeadEnd)
+		if err != nil {
+			continue
+		}
+
+		out.Solved++
+		length += len(plan)
+		for _, action := range plan {
+			usage[nameOf(action)]++
+		}
+	}
+
+	if out.Expanded > 0 {
+		out.Branching /= float64(out.Expanded)
+		out.DeadEnds /= float64(out.Expanded)
+	}
+	if out.Solved > 0 {
+		out.PlanLength = float64(length) / float64(out.Solved)
+	}
+
+	out.Blockers = dead.Blockers()
+	if len(out.Blockers) > maxBlockers {
+		out.Blockers = out.Blockers[:maxBlockers]
+	}
+
+	// Report every action, including the ones never used, in a stable order
+	out.Usage = make([]Usage, 0, len(actions))
+	for _, action := range actions {
+		out.Usage = append(out.Usage, Usage{Action: action, Count: usage[nameOf(action)]})
+	}
+
+	sort.SliceStable(out.Usage, func(i, j int) bool {
+		return out.Usage[i].Count > out.Usage[j].Count
+	})
+	return out
+}
+
+// String returns a human-readable report of the analysis.
+func (a Analysis) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "solved:     %d/%d\n", a.Solved, a.Samples)
+	fmt.Fprintf(&sb, "expanded:   %d\n", a.Expanded)
+	fmt.Fprintf(&sb, "branching:  %.2f\n", a.Branching)
+	fmt.Fprintf(&sb, "length:     %.2f\n", a.PlanLength)
+	fmt.Fprintf(&sb, "dead ends:  %.1f%%\n", a.DeadEnds*100)
+	for _, b := range a.Blockers {
+		fmt.Fprintf(&sb, "  blocked by %-15s %d\n", b.Rule, b.Count)
+	}
+	for _, u := range a.Usage {
+		fmt.Fprintf(&sb, "  %-20s %d\n", nameOf(u.Action), u.Count)
+	}
+	return sb.String()
+}
@@ -0,0 +1,43 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze(t *testing.T) {
+	actions := []Action{move("A->B"), move("B->C"), move("D->E")}
+	out := Analyze([]*State{
+		StateOf("A"),
+		StateOf("B"),
+		StateOf("!A", "!B"),
+	}, StateOf("C"), actions)
+
+	assert.Equal(t, 3, out.Samples)
+	assert.Equal(t, 2, out.Solved)
+	assert.Equal(t, 1.5, out.PlanLength)
+	assert.NotZero(t, out.Expanded)
+	assert.Greater(t, out.Branching, 0.0)
+	assert.Greater(t, out.DeadEnds, 0.0)
+
+	// Actions are ordered from the most to the least used
+	assert.Len(t, out.Usage, 3)
+	assert.Equal(t, "B->C", nameOf(out.Usage[0].Action))
+	assert.Equal(t, 2, out.Usage[0].Count)
+	assert.Equal(t, "D->E", nameOf(out.Usage[2].Action))
+	assert.Equal(t, 0, out.Usage[2].Count)
+	assert.Contains(t, out.String(), "solved:     2/3")
+}
+
+func TestCountersDeadEnd(t *testing.T) {
+	var stats Counters
+	_, err := Plan(StateOf("A"), StateOf("C"), []Action{move("A->B")}, WithCounters(&stats))
+	assert.Error(t, err)
+	assert.Equal(t, uint64(2), stats.Expand)
+	assert.Equal(t, uint64(1), stats.Branch)
+	assert.Equal(t, uint64(1), stats.DeadEnd)
+}
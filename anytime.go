@@ -0,0 +1,90 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	anytimeWeight = 5   // Initial weight of the heuristic for the anytime search
+	anytimeStep   = 256 // Number of nodes to expand between the deadline checks
+)
+
+var errDeadline = errors.New("plan: no plan could be found before the deadline")
+
+// PlanAnytime quickly finds a possibly suboptimal plan using a heavily weighted heuristic,
+// then keeps improving it until the deadline by restarting a weighted A* search with a
+// decreasing weight, only considering the plans cheaper than the best one found so far.
+// Unlike ARA*, every restart searches from scratch rather than reusing the previous one.
+// Every improved plan is passed to the callback, if any, along with its cost as accounted
+// by the search, including the cost weights, discounts and multipliers of the options. It
+// returns the best plan found before the deadline, which is optimal if the search completed.
+func PlanAnytime(start, goal *State, actions []Action, deadline time.Time, fn func(plan []Action, cost float32), opts ...Option) ([]Action, error) {
+	o := optionsOf(opts)
+	o.weight = anytimeWeight
+
+	// Only the best plan is recorded, rather than every improved one
+	analytics := o.analytics
+	o.analytics = nil
+
+	var best []Action
+	for {
+		plan, cost, err := runUntil(start, goalOf(goal), actions, o, deadline)
+		switch {
+		case err != nil && best == nil:
+			return nil, err
+		case err != nil: // Out of time or no cheaper plan exists
+			analytics.record(best)
+			return best, nil
+		}
+
+		// The search completed and found a cheaper plan than the previous one
+		best = plan
+		if fn != nil {
+			fn(plan, cost)
+		}
+
+		// The plan found without any inflation of the heuristic is the optimal one
+		if o.weight == 1 {
+			analytics.record(best)
+			return best, nil
+		}
+
+		o.budget = math.Nextafter32(cost, 0)
+		if o.weight = 1 + (o.weight-1)/2; o.weight < 1.1 {
+			o.weight = 1
+		}
+	}
+}
+
+// runUntil runs the search for a plan to reach the goal, giving up once past the deadline.
+// It returns the plan along with its cost, as accounted by the search.
+func runUntil(start *State, goal objective, actions []Action, o options, deadline time.Time) ([]Action, float32, error) {
+	var search Search
+	search.init(nil, start, goal, actions, o)
+	for !search.Step(anytimeStep) {
+		if time.Now().After(deadline) {
+			search.Close()
+			return nil, 0, errDeadline
+		}
+	}
+
+	if search.final != nil {
+		search.final.release()
+	}
+
+	plan, err := search.Result()
+	return plan, search.cost, err
+}
+
+// costOf returns the total cost of the plan.
+func costOf(plan []Action) (cost float32) {
+	for _, action := range plan {
+		cost += action.Cost()
+	}
+	return cost
+}
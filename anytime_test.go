@@ -0,0 +1,42 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanAnytime(t *testing.T) {
+	actions := []Action{move("A->C", 10), move("A->B"), move("B->C")}
+
+	// A heavily weighted heuristic prefers the direct, expensive route first
+	plan, err := Plan(StateOf("A"), StateOf("C"), actions, WithWeight(5))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C"}, planOf(plan))
+
+	var costs []float32
+	plan, err = PlanAnytime(StateOf("A"), StateOf("C"), actions, time.Now().Add(time.Second), func(_ []Action, cost float32) {
+		costs = append(costs, cost)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->C"}, planOf(plan))
+	assert.Equal(t, []float32{10, 2}, costs)
+
+	// The costs are the ones accounted by the search, here doubled by the weights
+	costs = costs[:0]
+	plan, err = PlanAnytime(StateOf("A"), StateOf("C"), actions, time.Now().Add(time.Second), func(_ []Action, cost float32) {
+		costs = append(costs, cost)
+	}, WithCostWeights(2))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->C"}, planOf(plan))
+	assert.Equal(t, []float32{20, 4}, costs)
+}
+
+func TestPlanAnytimeNoPlan(t *testing.T) {
+	_, err := PlanAnytime(StateOf("A"), StateOf("D"), []Action{move("A->B")}, time.Now().Add(time.Second), nil)
+	assert.ErrorContains(t, err, "no plan could be found")
+}
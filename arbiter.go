@@ -0,0 +1,80 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// Candidate represents a goal competing for the attention of an agent.
+type Candidate struct {
+	Name     string  // The unique name of the goal
+	Goal     *State  // The desired state of the goal
+	Priority float32 // The base priority of the goal
+}
+
+// Arbiter selects which goal an agent should pursue next among a set of candidates. The
+// priority of a goal decays every time it is selected or completed ("boredom") and then
+// slowly recovers, producing varied behavior without a hand-written scheduler. An arbiter
+// keeps the boredom of a single agent and is not safe for concurrent use.
+type Arbiter struct {
+	decay    float32            // Boredom gained on every selection
+	recovery float32            // Boredom recovered on every selection of another goal
+	boredom  map[string]float32 // Boredom of every goal, between 0 and 1
+}
+
+// NewArbiter creates a new arbiter. Every time a goal is selected its boredom grows by the
+// decay, while the boredom of the other goals shrinks by the recovery. The priority of a
+// goal is scaled down by its boredom, both being fractions between 0 and 1.
+func NewArbiter(decay, recovery float32) *Arbiter {
+	return &Arbiter{
+		decay:    clamp01(decay),
+		recovery: clamp01(recovery),
+		boredom:  make(map[string]float32, 8),
+	}
+}
+
+// Select selects the candidate with the highest priority, once decayed by the boredom,
+// among the ones not already achieved in the current state. It returns false if every
+// candidate is already achieved.
+func (a *Arbiter) Select(current *State, candidates []Candidate) (Candidate, bool) {
+	best, found := -1, false
+	bestScore := float32(0)
+	for i, c := range candidates {
+		if ok, err := current.Match(c.Goal); ok || err != nil {
+			continue
+		}
+
+		if score := a.Priority(c); !found || score > bestScore {
+			best, bestScore, found = i, score, true
+		}
+	}
+
+	if !found {
+		return Candidate{}, false
+	}
+
+	// Recover the boredom of the other goals and get bored of the selected one
+	selected := candidates[best]
+	for name, v := range a.boredom {
+		if name != selected.Name {
+			a.boredom[name] = clamp01(v - a.recovery)
+		}
+	}
+
+	a.boredom[selected.Name] = clamp01(a.boredom[selected.Name] + a.decay)
+	return selected, true
+}
+
+// Complete records the completion of a goal, making the agent fully bored of it until
+// it recovers, so the same goal is not immediately pursued again.
+func (a *Arbiter) Complete(name string) {
+	a.boredom[name] = 1
+}
+
+// Priority returns the priority of the candidate, once decayed by the boredom.
+func (a *Arbiter) Priority(c Candidate) float32 {
+	return c.Priority * (1 - a.boredom[c.Name])
+}
+
+// clamp01 clamps the value between 0 and 1.
+func clamp01(v float32) float32 {
+	return min(max(v, 0), 1)
+}
@@ -0,0 +1,37 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArbiter(t *testing.T) {
+	arbiter := NewArbiter(0.5, 0.25)
+	current := StateOf("!fed", "!rested", "bored")
+	candidates := []Candidate{
+		{Name: "eat", Goal: StateOf("fed"), Priority: 10},
+		{Name: "sleep", Goal: StateOf("rested"), Priority: 8},
+		{Name: "play", Goal: StateOf("bored"), Priority: 100},
+	}
+
+	// The goals already achieved are never selected, the others alternate due to boredom
+	var selected []string
+	for i := 0; i < 4; i++ {
+		c, ok := arbiter.Select(current, candidates)
+		assert.True(t, ok)
+		selected = append(selected, c.Name)
+	}
+	assert.Equal(t, []string{"eat", "sleep", "eat", "sleep"}, selected)
+
+	// A completed goal is not immediately pursued again
+	arbiter.Complete("sleep")
+	assert.Equal(t, float32(0), arbiter.Priority(candidates[1]))
+
+	// Nothing to select once every goal is achieved
+	_, ok := arbiter.Select(StateOf("fed", "rested", "bored"), candidates)
+	assert.False(t, ok)
+}
@@ -0,0 +1,257 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+// Package benchmark provides standardized planning domains and compares the search modes
+// of the planner on them, reporting the number of nodes expanded, the time spent and the
+// cost of the plans found relative to the optimal ones, so that the right mode for a
+// domain can be picked with data. Other search modes can be compared by providing
+// custom modes.
+package benchmark
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kelindar/goap"
+)
+
+// Domain represents a planning problem of the benchmark.
+type Domain struct {
+	Name    string        // The name of the domain
+	Start   *goap.State   // The start state
+	Goal    *goap.State   // The goal state
+	Actions []goap.Action // The set of actions
+}
+
+// PlanFunc represents a function finding a plan, such as goap.Plan.
+type PlanFunc func(start, goal *goap.State, actions []goap.Action, opts ...goap.Option) ([]goap.Action, error)
+
+// Mode represents a search mode of the planner to compare.
+type Mode struct {
+	Name  string        // The name of the mode
+	Plan  PlanFunc      // The function finding a plan with this mode
+	Nodes func() uint64 // The nodes expanded by the last plan, if not reported by the counters
+}
+
+// Result represents the result of a search mode on a domain.
+type Result struct {
+	Domain  string        // The name of the domain
+	Mode    string        // The name of the search mode
+	Nodes   uint64        // The number of nodes expanded
+	Elapsed time.Duration // The time spent planning
+	Length  int           // The number of actions of the plan
+	Cost    float32       // The total cost of the plan
+	Ratio   float32       // The cost of the plan relative to the optimal one, 1 if optimal
+	Err     error         // The error of the search, if any
+}
+
+// String returns the human-readable representation of the result.
+func (r Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%-10s %-12s error: %v", r.Domain, r.Mode, r.Err)
+	}
+
+	return fmt.Sprintf("%-10s %-12s nodes=%-6d time=%-12v length=%-3d cost=%-6v ratio=%.2f",
+		r.Domain, r.Mode, r.Nodes, r.Elapsed, r.Length, r.Cost, r.Ratio)
+}
+
+// Modes returns the search modes compared: the regular A* search, the weighted A* searches
+// which trade optimality for speed, the anytime search, the beam search which bounds the
+// memory of the open set, and the iterative deepening A* search which only keeps the
+// current path in memory.
+func Modes() []Mode {
+	return []Mode{
+		{Name: "astar", Plan: goap.Plan},
+		{Name: "weighted-2", Plan: weighted(2)},
+		{Name: "weighted-5", Plan: weighted(5)},
+		{Name: "anytime", Plan: anytime(50 * time.Millisecond)},
+		{Name: "beam-8", Plan: beam(8)},
+		idaStar(),
+	}
+}
+
+// weighted returns a weighted A* search mode.
+func weighted(weight float32) PlanFunc {
+	return func(start, goal *goap.State, actions []goap.Action, opts ...goap.Option) ([]goap.Action, error) {
+		return goap.Plan(start, goal, actions, append(opts, goap.WithWeight(weight))...)
+	}
+}
+
+// anytime returns an anytime search mode, improving the plan until the timeout.
+func anytime(timeout time.Duration) PlanFunc {
+	return func(start, goal *goap.State, actions []goap.Action, opts ...goap.Option) ([]goap.Action, error) {
+		return goap.PlanAnytime(start, goal, actions, time.Now().Add(timeout), nil, opts...)
+	}
+}
+
+// beam returns a beam search mode, keeping only the most promising states of the open set.
+func beam(width int) PlanFunc {
+	return func(start, goal *goap.State, actions []goap.Action, opts ...goap.Option) ([]goap.Action, error) {
+		return goap.Plan(start, goal, actions, append(opts, goap.WithOpenLimit(width, goap.PruneWorst))...)
+	}
+}
+
+// Run runs every search mode on every domain. The cost ratio of the plans is relative to
+// the plan found by the first mode, which is expected to be an optimal one.
+func Run(domains []Domain, modes []Mode) []Result {
+	results := make([]Result, 0, len(domains)*len(modes))
+	for _, d := range domains {
+		var optimal float32
+		for i, m := range modes {
+			r := measure(d, m)
+			if i == 0 && r.Err == nil {
+				optimal = r.Cost
+			}
+
+			switch {
+			case r.Err != nil:
+			case optimal > 0:
+				r.Ratio = r.Cost / optimal
+			case r.Cost == optimal:
+				r.Ratio = 1
+			}
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// measure runs the search mode on the domain.
+func measure(d Domain, m Mode) Result {
+	var counters goap.Counters
+	began := time.Now()
+	plan, err := m.Plan(d.Start, d.Goal, d.Actions, goap.WithCounters(&counters))
+	elapsed := time.Since(began)
+	if m.Nodes != nil {
+		counters.Expand = m.Nodes()
+	}
+
+	cost := float32(0)
+	for _, action := range plan {
+		cost += action.Cost()
+	}
+
+	return Result{
+		Domain:  d.Name,
+		Mode:    m.Name,
+		Nodes:   counters.Expand,
+		Elapsed: elapsed,
+		Length:  len(plan),
+		Cost:    cost,
+		Err:     err,
+	}
+}
+
+// ------------------------------------ Domains ------------------------------------
+
+// Domains returns the standardized domains of the benchmark.
+func Domains() []Domain {
+	return []Domain{Deep(), Wide(16), Maze(), Crafting()}
+}
+
+// Deep returns a numeric domain requiring a long plan, balancing hunger and tiredness
+// while gathering food.
+func Deep() Domain {
+	return Domain{
+		Name:  "deep",
+		Start: goap.StateOf("hunger=80", "!food", "!tired"),
+		Goal:  goap.StateOf("food>80"),
+		Actions: define(
+			action{"eat", 1, "food>0", "hunger-50,food-5"},
+			action{"forage", 1, "tired<50", "tired+20,food+10,hunger+5"},
+			action{"sleep", 1, "tired>30", "tired-50"},
+		),
+	}
+}
+
+// Wide returns a symbolic domain with many boolean facts to achieve, each of them either
+// directly at a high cost or at a lower cost once the previous one is achieved.
+func Wide(facts int) Domain {
+	start := make([]string, 0, facts)
+	goal := make([]string, 0, facts)
+	actions := make([]action, 0, 2*facts)
+	for i := 0; i < facts; i++ {
+		name := "f" + strconv.Itoa(i)
+		start = append(start, "!"+name)
+		goal = append(goal, name)
+		actions = append(actions, action{"set " + name, 3, "!" + name, name})
+		if i > 0 {
+			prev := "f" + strconv.Itoa(i-1)
+			actions = append(actions, action{"chain " + name, 1, prev + ",!" + name, name})
+		}
+	}
+
+	return Domain{
+		Name:    "wide",
+		Start:   goap.StateOf(start...),
+		Goal:    goap.StateOf(goal...),
+		Actions: define(actions...),
+	}
+}
+
+// Maze returns a symbolic domain of moves between rooms, with dead ends and shortcuts.
+func Maze() Domain {
+	moves := []string{
+		"A->B", "B->C", "C->D", "D->E", "E->F", "F->G", "G->H", "H->I", "I->J",
+		"C->X1", "E->X2", "G->X3", "X1->D", "X2->F", "X3->H", "B->Y1", "D->Y2", "F->Y3",
+		"Y1->C", "Y2->E", "Y3->G", "J->K", "K->L", "L->M", "M->N", "N->O", "O->P", "P->Q",
+		"Q->R", "R->S", "S->T", "T->U", "U->V", "V->W", "W->X", "X->Y", "Y->Z", "U->Z1",
+		"W->Z2", "Z1->V", "Z2->X", "A->Z3",
+	}
+
+	actions := make([]action, 0, len(moves))
+	for _, m := range moves {
+		from, to, _ := strings.Cut(m, "->")
+		actions = append(actions, action{m, 1, from, "!" + from + "," + to})
+	}
+
+	return Domain{
+		Name:    "maze",
+		Start:   goap.StateOf("A"),
+		Goal:    goap.StateOf("Z"),
+		Actions: define(actions...),
+	}
+}
+
+// Crafting returns a crafting domain, gathering raw materials to craft a tool.
+func Crafting() Domain {
+	actions, err := goap.Craft(
+		goap.Recipe{Name: "chop wood", Cost: 2, Outputs: map[string]float32{"wood": 1}, Tools: []string{"axe"}},
+		goap.Recipe{Name: "mine ore", Cost: 3, Outputs: map[string]float32{"ore": 1}, Tools: []string{"pickaxe"}},
+		goap.Recipe{Name: "craft plank", Cost: 1, Inputs: map[string]float32{"wood": 1}, Outputs: map[string]float32{"plank": 2}},
+		goap.Recipe{Name: "smelt ingot", Cost: 2, Inputs: map[string]float32{"ore": 2, "wood": 1}, Outputs: map[string]float32{"ingot": 1}},
+		goap.Recipe{Name: "craft handle", Cost: 1, Inputs: map[string]float32{"plank": 1}, Outputs: map[string]float32{"handle": 1}},
+		goap.Recipe{Name: "craft sword", Cost: 1, Inputs: map[string]float32{"ingot": 2, "handle": 1}, Outputs: map[string]float32{"sword": 1}},
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	return Domain{
+		Name:    "crafting",
+		Start:   goap.StateOf("axe", "pickaxe", "!wood", "!ore", "!plank", "!ingot", "!handle", "!sword"),
+		Goal:    goap.StateOf("sword=1"),
+		Actions: actions,
+	}
+}
+
+// action represents the definition of an action from comma-separated rules.
+type action struct {
+	name    string
+	cost    float32
+	require string
+	outcome string
+}
+
+// define defines the actions.
+func define(actions ...action) []goap.Action {
+	set, _ := goap.NewActionSet()
+	for _, a := range actions {
+		if err := set.Define(a.name, a.cost, strings.Split(a.require, ","), strings.Split(a.outcome, ",")); err != nil {
+			panic(err)
+		}
+	}
+	return set.Actions()
+}
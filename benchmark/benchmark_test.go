@@ -0,0 +1,46 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package benchmark
+
+import (
+	"testing"
+
+	"github.com/kelindar/goap"
+	"github.com/stretchr/testify/assert"
+)
+
+func BenchmarkModes(b *testing.B) {
+	for _, d := range Domains() {
+		for _, m := range Modes() {
+			b.Run(d.Name+"/"+m.Name, func(b *testing.B) {
+				var counters goap.Counters
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_, err := m.Plan(d.Start, d.Goal, d.Actions, goap.WithCounters(&counters))
+					assert.NoError(b, err)
+				}
+				if m.Nodes != nil {
+					counters.Expand = m.Nodes()
+				}
+				b.ReportMetric(float64(counters.Expand), "nodes/op")
+			})
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	results := Run(Domains(), Modes())
+	assert.Len(t, results, 24)
+	for _, r := range results {
+		t.Log(r)
+		assert.NoError(t, r.Err, r.Domain+"/"+r.Mode)
+		assert.NotZero(t, r.Length)
+		assert.NotZero(t, r.Nodes)
+		assert.GreaterOrEqual(t, r.Ratio, float32(1))
+		if r.Mode == "astar" {
+			assert.Equal(t, float32(1), r.Ratio)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package benchmark
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/kelindar/goap"
+)
+
+const (
+	idaDepth = 64      // Maximum depth of the paths explored by IDA*
+	idaNodes = 1 << 20 // Maximum number of nodes expanded by IDA* before giving up
+	idaTable = 1 << 16 // Maximum number of states remembered by IDA* in every iteration
+)
+
+var errNoPlan = errors.New("benchmark: no plan could be found by IDA*")
+
+// idaStar returns the iterative deepening A* search mode. It explores the paths depth-first
+// up to a bound on their estimated total cost, raised to the smallest cost exceeding it on
+// every iteration, hence it only keeps the current path and a bounded transposition table
+// in memory, but expands the same nodes again on every iteration. It only relies on the
+// public API of the planner, so the options are ignored and the actions are simulated
+// with Simulate().
+func idaStar() Mode {
+	var search ida
+	return Mode{
+		Name: "ida*",
+		Plan: func(start, goal *goap.State, actions []goap.Action, _ ...goap.Option) ([]goap.Action, error) {
+			search = ida{goal: goal, actions: actions}
+			return search.run(start)
+		},
+		Nodes: func() uint64 {
+			return search.nodes
+		},
+	}
+}
+
+// ida represents a single iterative deepening A* search.
+type ida struct {
+	goal    *goap.State        // The goal of the search
+	actions []goap.Action      // The set of actions
+	path    []*goap.State      // The states of the current path, from the start
+	steps   []goap.Action      // The actions of the current path
+	seen    map[uint32]float32 // The cheapest cost at which the states were reached, by hash
+	nodes   uint64             // The number of nodes expanded
+}
+
+// run finds a plan from the start state to the goal.
+func (s *ida) run(start *goap.State) ([]goap.Action, error) {
+	bound := start.Distance(s.goal)
+	s.path = append(s.path[:0], start)
+	s.seen = make(map[uint32]float32, 64)
+	for {
+		clear(s.seen)
+		next, found, err := s.visit(start, 0, bound)
+		switch {
+		case err != nil:
+			return nil, err
+		case found:
+			return s.steps, nil
+		case math.IsInf(float64(next), 1):
+			return nil, errNoPlan
+		}
+		bound = next
+	}
+}
+
+// visit explores the paths from the state whose estimated total cost is within the bound.
+// It returns whether the goal was found, or otherwise the smallest estimated total cost
+// exceeding the bound.
+func (s *ida) visit(state *goap.State, cost, bound float32) (float32, bool, error) {
+	if total := cost + state.Distance(s.goal); total > bound {
+		return total, false, nil
+	}
+
+	switch done, err := state.Match(s.goal); {
+	case err != nil:
+		return 0, false, err
+	case done:
+		return cost, true, nil
+	case len(s.path) > idaDepth:
+		return float32(math.Inf(1)), false, nil
+	case s.nodes >= idaNodes:
+		return 0, false, errNoPlan
+	}
+
+	// A state already reached as cheaply in this iteration has nothing new to explore
+	if seen, ok := s.seen[state.Hash()]; ok && seen <= cost {
+		return float32(math.Inf(1)), false, nil
+	}
+	if len(s.seen) < idaTable {
+		s.seen[state.Hash()] = cost
+	}
+
+	s.nodes++
+	next := float32(math.Inf(1))
+	for _, c := range s.children(state, cost) {
+		s.path = append(s.path, c.state)
+		s.steps = append(s.steps, c.action)
+		t, found, err := s.visit(c.state, c.cost, bound)
+		if found || err != nil {
+			return t, found, err
+		}
+
+		s.path = s.path[:len(s.path)-1]
+		s.steps = s.steps[:len(s.steps)-1]
+		next = min(next, t)
+	}
+	return next, false, nil
+}
+
+// child represents a state reachable from the state being expanded.
+type child struct {
+	action goap.Action // The action leading to the state
+	state  *goap.State // The state reached
+	cost   float32     // The cost of the path to the state
+	total  float32     // The estimated total cost of the path through the state
+}
+
+// children returns the states reachable from the state which are not already on the
+// current path, the most promising ones first.
+func (s *ida) children(state *goap.State, cost float32) []child {
+	out := make([]child, 0, len(s.actions))
+	for _, action := range s.actions {
+		require, outcome := action.Simulate(state)
+		if ok, err := state.Match(require); err != nil || !ok {
+			continue
+		}
+
+		next := state.Clone()
+		if err := next.Apply(outcome); err != nil || s.cycles(next) {
+			continue
+		}
+
+		c := cost + action.Cost()
+		out = append(out, child{action: action, state: next, cost: c, total: c + next.Distance(s.goal)})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].total < out[j].total })
+	return out
+}
+
+// cycles returns whether the state already appears on the current path.
+func (s *ida) cycles(state *goap.State) bool {
+	for _, visited := range s.path {
+		if visited.Equals(state) {
+			return true
+		}
+	}
+	return false
+}
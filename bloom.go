@@ -0,0 +1,78 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "math"
+
+// defaultFalsePositive is the default false-positive rate of the Bloom filter.
+const defaultFalsePositive = 0.01
+
+// WithBloomFilter puts a Bloom filter in front of the set of the visited states, sized for
+// the expected number of states at the false-positive rate, 1% if not between 0 and 1. The
+// states which were never seen are rejected by the filter without looking up the visited
+// set. Once the visited set holds the expected number of states, the new ones are only
+// tracked by the filter, which bounds its memory: the search becomes approximate, as
+// states falsely reported as seen are pruned, and the filter degrades past its size.
+func WithBloomFilter(expected int, rate float64) Option {
+	return func(o *options) {
+		o.bloomSize = max(expected, 0)
+		o.bloomRate = rate
+		if !(rate > 0 && rate < 1) {
+			o.bloomRate = defaultFalsePositive
+		}
+	}
+}
+
+// bloom represents a Bloom filter of the keys of the visited states.
+type bloom struct {
+	bits   []uint64 // The bits of the filter
+	hashes int      // The number of hash functions
+	limit  int      // The number of states tracked exactly, before relying on the filter
+}
+
+// newBloom creates a new Bloom filter sized for the expected number of keys at the
+// false-positive rate.
+func newBloom(expected int, rate float64) *bloom {
+	n := float64(max(expected, 1))
+	m := math.Ceil(-n * math.Log(rate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / n * math.Ln2)
+	return &bloom{
+		bits:   make([]uint64, (int(m)+63)/64),
+		hashes: max(int(k), 1),
+		limit:  expected,
+	}
+}
+
+// reset clears the filter so it can be reused.
+func (b *bloom) reset() {
+	clear(b.bits)
+}
+
+// Add adds the key to the filter.
+func (b *bloom) Add(key uint32) {
+	h1, h2, m := b.hash(key)
+	for i := 0; i < b.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Contains returns whether the key may have been added to the filter, false meaning that
+// it definitely was not.
+func (b *bloom) Contains(key uint32) bool {
+	h1, h2, m := b.hash(key)
+	for i := 0; i < b.hashes; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hash returns the two hashes of the key used for double hashing, and the number of bits.
+func (b *bloom) hash(key uint32) (uint64, uint64, uint64) {
+	x := mix(uint64(key) + 0x9e3779b97f4a7c15)
+	return x & 0xFFFFFFFF, x>>32 | 1, uint64(len(b.bits)) * 64
+}
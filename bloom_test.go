@@ -0,0 +1,51 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloom(t *testing.T) {
+	filter := newBloom(1000, 0.01)
+	for i := uint32(0); i < 1000; i++ {
+		filter.Add(i * 7919)
+	}
+
+	// Every added key is reported, with few false positives for the others
+	positives := 0
+	for i := uint32(0); i < 1000; i++ {
+		assert.True(t, filter.Contains(i*7919))
+		if filter.Contains(i*7919 + 1) {
+			positives++
+		}
+	}
+	assert.Less(t, positives, 50)
+
+	filter.reset()
+	assert.False(t, filter.Contains(0))
+}
+
+func TestBloomFilter(t *testing.T) {
+	start, goal := StateOf("A", "!B", "!C", "!D", "!E"), StateOf("E")
+	actions := []Action{
+		move("A->B"), move("B->C"), move("C->D"), move("D->E"),
+		move("A->C", 3), move("B->D", 3), move("C->E", 3),
+	}
+
+	expect, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+
+	// With enough room, the search remains exact
+	plan, err := Plan(start, goal, actions, WithBloomFilter(1000, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, planOf(expect), planOf(plan))
+
+	// Past the limit, the search becomes approximate but still finds a plan
+	plan, err = Plan(start, goal, actions, WithBloomFilter(2, 0.1), WithoutPool())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, plan)
+}
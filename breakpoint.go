@@ -0,0 +1,82 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "errors"
+
+// ErrStopped is returned when the callback of a breakpoint stops the search.
+var ErrStopped = errors.New("plan: search stopped at a breakpoint")
+
+// Breakpoint represents a condition which pauses the search and hands the control to a
+// callback, such as a fact reaching a value or an action being applied. When both of the
+// conditions are set, both must hold. Every breakpoint is hit at most once per search.
+type Breakpoint struct {
+	State  *State // The conditions a new node must match, if any
+	Action string // The name of the action applied to reach the node, if any
+}
+
+// Hit represents a breakpoint being hit during the search.
+type Hit struct {
+	Breakpoint Breakpoint // The breakpoint which was hit
+	Action     Action     // The action applied to reach the node
+	State      *State     // The node reached, which must not be modified nor retained
+	Path       []Action   // The actions leading to the node from the start
+	Cost       float32    // The cost of the path leading to the node
+}
+
+// breakpoint represents a breakpoint along with its callback.
+type breakpoint struct {
+	Breakpoint
+	fn func(Hit) bool
+}
+
+// matches returns whether the breakpoint is hit by the action leading to the node.
+func (b *breakpoint) matches(action Action, node *State) (bool, error) {
+	if b.Action != "" && nameOf(action) != b.Action {
+		return false, nil
+	}
+
+	if b.State != nil {
+		return node.Match(b.State)
+	}
+	return true, nil
+}
+
+// pause checks the breakpoints against the new node reached by the action and calls the
+// callbacks of the ones being hit. It returns false if any of the callbacks stopped the
+// search.
+func (s *Search) pause(action Action, node *State) (bool, error) {
+	if s.hits == nil {
+		s.hits = make([]bool, len(s.options.breaks))
+	}
+
+	for i := range s.options.breaks {
+		bp := &s.options.breaks[i]
+		if s.hits[i] {
+			continue
+		}
+
+		match, err := bp.matches(action, node)
+		s.stats.Match++
+		switch {
+		case err != nil:
+			return false, err
+		case !match:
+			continue
+		}
+
+		s.hits[i] = true
+		if !bp.fn(Hit{
+			Breakpoint: bp.Breakpoint,
+			Action:     action,
+			State:      node,
+			Path:       reconstructPlan(append([]Action(nil), s.dst...), node),
+			Cost:       node.stateCost,
+		}) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
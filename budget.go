@@ -0,0 +1,63 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "time"
+
+// budgetStep is the number of nodes to expand between the checks of the frame budget.
+const budgetStep = 64
+
+// PlanBudgeted searches for a plan to reach the goal until the wall-clock budget is spent,
+// so that planning never exceeds the time of a frame. If the search completes within the
+// budget, it returns the plan found. Otherwise it returns the search, which is resumed on
+// the following frames with StepFor(), along with the best partial plan found so far,
+// leading to the state closest to the goal, which the agent may start performing while
+// the search continues.
+func (p *Planner) PlanBudgeted(start, goal *State, budget time.Duration, opts ...Option) ([]Action, *Search, error) {
+	search := p.Start(start, goal, opts...)
+	if search.StepFor(budget) {
+		plan, err := search.Result()
+		return plan, nil, err
+	}
+
+	return search.Partial(), search, nil
+}
+
+// StepFor expands the nodes of the search graph until the wall-clock budget is spent and
+// returns whether the search is done. At least a few nodes are expanded on every call.
+func (s *Search) StepFor(budget time.Duration) bool {
+	deadline := time.Now().Add(budget)
+	for !s.Step(budgetStep) {
+		if !time.Now().Before(deadline) {
+			return false
+		}
+	}
+	return true
+}
+
+// Partial returns the best partial plan found so far, leading to the expanded state with
+// the lowest estimated distance to the goal. Once the search is done, it returns the plan
+// found instead, if any.
+func (s *Search) Partial() []Action {
+	switch {
+	case s.done:
+		plan, _ := s.Result()
+		return plan
+	case s.best == nil:
+		return append([]Action(nil), s.dst...)
+	default:
+		return reconstructPlan(append([]Action(nil), s.dst...), s.best)
+	}
+}
+
+// closer records the node as the best partial plan if it is closer to the goal than the
+// previous one, or as close but cheaper.
+func (s *Search) closer(node *State) {
+	switch {
+	case s.best == nil,
+		node.heuristic < s.best.heuristic,
+		node.heuristic == s.best.heuristic && node.stateCost < s.best.stateCost:
+		s.best = node
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanBudgeted(t *testing.T) {
+	planner, err := NewPlanner([]Action{
+		actionOf("Step", 1, StateOf("n<100"), StateOf("n+1")),
+		actionOf("Back", 1, StateOf("n>0"), StateOf("n-1")),
+	})
+	assert.NoError(t, err)
+
+	// A small search completes within the budget
+	plan, search, err := planner.PlanBudgeted(StateOf("n=95"), StateOf("n=100"), time.Second)
+	assert.NoError(t, err)
+	assert.Nil(t, search)
+	assert.Len(t, plan, 5)
+
+	// A larger one yields the best partial plan and a search to resume on the next frames
+	plan, search, err = planner.PlanBudgeted(StateOf("n=0"), StateOf("n=100"), 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, search)
+	assert.NotEmpty(t, plan)
+	assert.Less(t, len(plan), 100)
+	for _, action := range plan {
+		assert.Equal(t, "Step", action.(*testAction).name)
+	}
+
+	for !search.StepFor(time.Millisecond) {
+		assert.GreaterOrEqual(t, len(search.Partial()), len(plan))
+	}
+
+	plan, err = search.Result()
+	assert.NoError(t, err)
+	assert.Len(t, plan, 100)
+	assert.Equal(t, plan, search.Partial())
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+// Command capi exposes the planner through a flat C API, so that game engines such as
+// Unity, Unreal or Godot can call it directly from a native plugin. Build it with
+//
+//	go build -buildmode=c-shared -o libgoap.so ./capi
+//
+// which also generates the libgoap.h header. States and domains are referenced by opaque
+// handles which must be freed, rules are passed as comma-separated lists such as
+// "hunger>50,!food", and the functions returning an int return -1 on failure, the
+// message of the error being available through goap_last_error until the next call. The
+// handles may be used from several threads, but the last error is shared by all of them,
+// hence the threads must not interleave a failing call with its goap_last_error.
+package main
+
+/*
+#include <stdlib.h>
+#include <stdint.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"strings"
+	"unsafe"
+
+	"github.com/kelindar/goap"
+)
+
+// goap_state_new creates a new state from a comma-separated list of rules and returns
+// its handle, or 0 if any of the rules is invalid.
+//
+//export goap_state_new
+func goap_state_new(rules *C.char) C.uint64_t {
+	reset()
+	h, err := newState(C.GoString(rules))
+	if err != nil {
+		fail(err)
+		return 0
+	}
+	return C.uint64_t(h)
+}
+
+// goap_state_apply applies the comma-separated effects, such as "hunger-10", to the state.
+//
+//export goap_state_apply
+func goap_state_apply(state C.uint64_t, effects *C.char) C.int {
+	reset()
+	if err := apply(uint64(state), C.GoString(effects)); err != nil {
+		return C.int(fail(err))
+	}
+	return 0
+}
+
+// goap_state_string returns the string representation of the state, which must be
+// released with goap_free, or NULL if the handle is invalid.
+//
+//export goap_state_string
+func goap_state_string(state C.uint64_t) *C.char {
+	reset()
+	s, err := stringOf(uint64(state))
+	if err != nil {
+		fail(err)
+		return nil
+	}
+	return C.CString(s)
+}
+
+// goap_domain_new creates a new empty domain (set of actions) and returns its handle.
+//
+//export goap_domain_new
+func goap_domain_new() C.uint64_t {
+	reset()
+	set, _ := goap.NewActionSet()
+	return C.uint64_t(store(set))
+}
+
+// goap_domain_define defines an action of the domain from the comma-separated lists of
+// its requirements and outcomes, replacing any existing action with the same name.
+//
+//export goap_domain_define
+func goap_domain_define(domain C.uint64_t, name *C.char, cost C.float, require, outcome *C.char) C.int {
+	reset()
+	if err := define(uint64(domain), C.GoString(name), float32(cost), C.GoString(require), C.GoString(outcome)); err != nil {
+		return C.int(fail(err))
+	}
+	return 0
+}
+
+// goap_plan finds a plan to reach the goal from the start state using the actions of the
+// domain. The names of the actions are written into out as a comma-separated list, which
+// must be released with goap_free. It returns the number of actions of the plan.
+//
+//export goap_plan
+func goap_plan(domain, start, goal C.uint64_t, out **C.char) C.int {
+	reset()
+	if out == nil {
+		return C.int(fail(errors.New("goap: output pointer is null")))
+	}
+
+	names, err := plan(uint64(domain), uint64(start), uint64(goal))
+	if err != nil {
+		*out = nil
+		return C.int(fail(err))
+	}
+
+	*out = C.CString(strings.Join(names, ","))
+	return C.int(len(names))
+}
+
+// goap_release releases the state or the domain of the handle.
+//
+//export goap_release
+func goap_release(handle C.uint64_t) {
+	reset()
+	free(uint64(handle))
+}
+
+// goap_free frees a string returned by the library.
+//
+//export goap_free
+func goap_free(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
+
+// goap_last_error returns the message of the error of the last call, which must be released
+// with goap_free, or NULL if the last call succeeded.
+//
+//export goap_last_error
+func goap_last_error() *C.char {
+	lastError.Lock()
+	defer lastError.Unlock()
+	if lastError.msg == "" {
+		return nil
+	}
+	return C.CString(lastError.msg)
+}
@@ -0,0 +1,202 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kelindar/goap"
+)
+
+// handles keeps the Go objects referenced by the native code alive, since pointers to
+// Go memory cannot be retained by C. A zero handle is never allocated.
+var handles struct {
+	sync.Mutex
+	next    uint64
+	objects map[uint64]*handle
+}
+
+// handle represents an object referenced by the native code, along with the lock which
+// serializes its use across the native threads, since states are not safe for concurrent use.
+type handle struct {
+	sync.Mutex
+	value any
+}
+
+// lastError is the message of the error of the last call, returned by goap_last_error.
+var lastError struct {
+	sync.Mutex
+	msg string
+}
+
+// main is required by the c-shared build mode, but is never called.
+func main() {}
+
+// store retains the object and returns its handle.
+func store(v any) uint64 {
+	handles.Lock()
+	defer handles.Unlock()
+	if handles.objects == nil {
+		handles.objects = make(map[uint64]*handle, 16)
+	}
+
+	handles.next++
+	handles.objects[handles.next] = &handle{value: v}
+	return handles.next
+}
+
+// load returns the object of the handle, if it is of the expected type. The object must
+// be safe for concurrent use, otherwise use must be called instead.
+func load[T any](h uint64) (T, error) {
+	_, out, err := lookup[T](h)
+	return out, err
+}
+
+// use calls the function with the object of the handle, if it is of the expected type,
+// while holding the lock of the handle.
+func use[T any](h uint64, fn func(T) error) error {
+	o, v, err := lookup[T](h)
+	if err != nil {
+		return err
+	}
+
+	o.Lock()
+	defer o.Unlock()
+	return fn(v)
+}
+
+// lookup returns the handle and its object, if it is of the expected type.
+func lookup[T any](h uint64) (*handle, T, error) {
+	handles.Lock()
+	o, ok := handles.objects[h]
+	handles.Unlock()
+
+	var out T
+	if !ok {
+		return nil, out, fmt.Errorf("goap: invalid handle %d", h)
+	}
+
+	out, match := o.value.(T)
+	if !match {
+		return nil, out, fmt.Errorf("goap: handle %d is a %T", h, o.value)
+	}
+	return o, out, nil
+}
+
+// free releases the object of the handle.
+func free(h uint64) {
+	handles.Lock()
+	defer handles.Unlock()
+	delete(handles.objects, h)
+}
+
+// fail records the error as the last error and returns the error status.
+func fail(err error) int {
+	lastError.Lock()
+	defer lastError.Unlock()
+	lastError.msg = err.Error()
+	return -1
+}
+
+// reset clears the last error at the start of every call, so that a successful call never
+// reports the error of a previous one.
+func reset() {
+	lastError.Lock()
+	defer lastError.Unlock()
+	lastError.msg = ""
+}
+
+// rulesOf splits a comma-separated list of rules, such as "hunger>50,!food".
+func rulesOf(s string) []string {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+
+	rules := strings.Split(s, ",")
+	for i := range rules {
+		rules[i] = strings.TrimSpace(rules[i])
+	}
+	return rules
+}
+
+// newState creates a new state from a comma-separated list of rules.
+func newState(rules string) (uint64, error) {
+	state, err := goap.TryStateOf(rulesOf(rules)...)
+	if err != nil {
+		return 0, err
+	}
+	return store(state), nil
+}
+
+// define defines an action of the domain from comma-separated lists of rules.
+func define(domain uint64, name string, cost float32, require, outcome string) error {
+	set, err := load[*goap.ActionSet](domain)
+	if err != nil {
+		return err
+	}
+	return set.Define(name, cost, rulesOf(require), rulesOf(outcome))
+}
+
+// plan finds a plan to reach the goal from the start state with the actions of the domain
+// and returns the names of its actions.
+func plan(domain, start, goal uint64) ([]string, error) {
+	set, err := load[*goap.ActionSet](domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plan with copies of the states, which may be modified by other threads meanwhile
+	from, err := cloneOf(start)
+	if err != nil {
+		return nil, err
+	}
+
+	into, err := cloneOf(goal)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, err := set.Plan(from, into)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(actions))
+	for _, action := range actions {
+		names = append(names, goap.DescribeAction(action).Name)
+	}
+	return names, nil
+}
+
+// apply applies the comma-separated effects to the state.
+func apply(state uint64, effects string) error {
+	e, err := goap.TryStateOf(rulesOf(effects)...)
+	if err != nil {
+		return err
+	}
+
+	return use(state, func(s *goap.State) error {
+		return s.Apply(e)
+	})
+}
+
+// cloneOf returns a copy of the state.
+func cloneOf(state uint64) (out *goap.State, err error) {
+	err = use(state, func(s *goap.State) error {
+		out = s.Clone()
+		return nil
+	})
+	return
+}
+
+// stringOf returns the string representation of the state.
+func stringOf(state uint64) (out string, err error) {
+	err = use(state, func(s *goap.State) error {
+		out = s.String()
+		return nil
+	})
+	return
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/kelindar/goap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlan(t *testing.T) {
+	set, _ := goap.NewActionSet()
+	domain := store(set)
+	defer free(domain)
+
+	assert.NoError(t, define(domain, "eat", 1, "food>0", "hunger-50, food-5"))
+	assert.NoError(t, define(domain, "forage", 1, "", "food+10"))
+	assert.Error(t, define(domain, "sleep", 1, "tired>>30", ""))
+
+	start, err := newState("hunger=80,!food")
+	assert.NoError(t, err)
+	defer free(start)
+
+	goal, err := newState("hunger<50")
+	assert.NoError(t, err)
+	defer free(goal)
+
+	names, err := plan(domain, start, goal)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"forage", "eat"}, names)
+
+	// Once fed, nothing needs to be done
+	assert.NoError(t, apply(start, "hunger=10"))
+	names, err = plan(domain, start, goal)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestHandles(t *testing.T) {
+	_, err := newState("hunger=")
+	assert.Error(t, err)
+
+	state, err := newState("hunger=80")
+	assert.NoError(t, err)
+
+	_, err = load[*goap.ActionSet](state)
+	assert.ErrorContains(t, err, "is a *goap.State")
+
+	free(state)
+	_, err = load[*goap.State](state)
+	assert.ErrorContains(t, err, "invalid handle")
+	assert.Equal(t, -1, fail(err))
+	assert.Contains(t, lastError.msg, "invalid handle")
+
+	// A successful call doesn't report the error of a previous one
+	reset()
+	assert.Empty(t, lastError.msg)
+}
+
+func TestConcurrentUse(t *testing.T) {
+	set, _ := goap.NewActionSet()
+	domain := store(set)
+	defer free(domain)
+	assert.NoError(t, define(domain, "forage", 1, "", "food+10"))
+
+	start, _ := newState("!food")
+	goal, _ := newState("food>50")
+	defer free(start)
+	defer free(goal)
+
+	// The state is modified by a thread while another one plans with it
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, apply(start, "food=0"))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_, err := plan(domain, start, goal)
+			assert.NoError(t, err)
+		}
+	}()
+	wg.Wait()
+
+	s, err := stringOf(start)
+	assert.NoError(t, err)
+	assert.Equal(t, "{food=0}", s)
+}
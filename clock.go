@@ -0,0 +1,67 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// TimeFact is the name of the built-in fact tracking the simulated time, which is advanced
+// by the durations of the actions during the search. It can be used in the preconditions,
+// such as "time<60" for a shop which is only open in the morning, or in the goals, such as
+// "time<80" to arrive before a deadline. Its unit and range can be set with DeclareFact.
+const TimeFact = "time"
+
+// timeFact is the precomputed built-in time fact.
+var timeFact = factOf(TimeFact)
+
+// TimedAction can be optionally implemented by actions which take time to perform. The
+// built-in time fact is advanced by the duration of the action once it is applied.
+type TimedAction interface {
+
+	// Duration returns the duration of the action, in the unit of the time fact.
+	Duration() float32
+}
+
+// WindowedAction can be optionally implemented by actions which are only available
+// during some windows of the simulated time or phase, such as at night or out of combat.
+// The window is evaluated as an implicit precondition of the action, so it does not need
+// to be repeated in the requirements returned by Simulate.
+type WindowedAction interface {
+
+	// Window returns the conditions, typically on the time fact or on a phase fact,
+	// under which the action is available, such as "time>20" and "time<60".
+	Window() *State
+}
+
+// available returns whether the action is available in the state, given its window.
+func available(state *State, action Action) (bool, error) {
+	windowed, ok := action.(WindowedAction)
+	if !ok {
+		return true, nil
+	}
+
+	window := windowed.Window()
+	if window == nil {
+		return true, nil
+	}
+	return state.Match(window)
+}
+
+// advance advances the time fact of the state by the duration of the action, if any.
+func advance(state *State, action Action, overflow Overflow) error {
+	timed, ok := action.(TimedAction)
+	if !ok {
+		return nil
+	}
+
+	duration := timed.Duration()
+	if duration <= 0 {
+		return nil
+	}
+
+	// The duration is expressed in the declared unit of the time fact, if any
+	if d, ok := domainOf(timeFact); ok {
+		duration = d.normalize(opIncrement, duration)
+	}
+
+	delta := exprOf(opIncrement, duration).Fixed()
+	return state.storeFixed(timeFact, state.load(timeFact).Fixed()+delta, overflow)
+}
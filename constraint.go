@@ -0,0 +1,117 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxIncluded is the maximum number of distinct actions a plan can be required to include.
+const maxIncluded = 64
+
+// WithMustInclude requires the plan to include every one of the named actions at least
+// once, such as the scripted beats of a story. The requirement is enforced by the search,
+// which tracks the required actions performed along every path, so the cheapest plan
+// including them is found. At most 64 distinct actions can be required.
+func WithMustInclude(names ...string) Option {
+	return func(o *options) {
+		o.include = append(o.include[:len(o.include):len(o.include)], names...)
+	}
+}
+
+// WithMustAvoid prevents the plan from including any of the named actions, such as the
+// actions restricted by the story. The actions are never expanded by the search.
+func WithMustAvoid(names ...string) Option {
+	return func(o *options) {
+		o.avoid = append(o.avoid[:len(o.avoid):len(o.avoid)], names...)
+	}
+}
+
+// avoid returns the actions except the ones which must be avoided.
+func avoid(actions []Action, names []string) []Action {
+	return filter(actions, func(action Action) bool {
+		name := nameOf(action)
+		for _, avoided := range names {
+			if name == avoided {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// included returns the bit of every action required to be included in the plan, and the
+// bits of all the required actions.
+func included(actions []Action, names []string) ([]uint64, uint64, error) {
+	bits := make(map[string]uint64, len(names))
+	for _, name := range names {
+		if _, ok := bits[name]; !ok {
+			if len(bits) >= maxIncluded {
+				return nil, 0, fmt.Errorf("%w, at most %d actions can be required", ErrTooManyActions, maxIncluded)
+			}
+			bits[name] = 1 << len(bits)
+		}
+	}
+
+	var all uint64
+	mask := make([]uint64, len(actions))
+	for i, action := range actions {
+		mask[i] = bits[nameOf(action)]
+		all |= mask[i]
+	}
+
+	for _, name := range names {
+		if all&bits[name] == 0 {
+			return nil, 0, &ActionError{Action: name, Err: fmt.Errorf("plan: required action '%s' is not available", name)}
+		}
+	}
+	return mask, all, nil
+}
+
+// performed returns the bits of the required actions performed by the plan.
+func (s *Search) performed(plan []Action) (bits uint64) {
+	if s.include == nil {
+		return 0
+	}
+
+	for _, action := range plan {
+		name := nameOf(action)
+		for i, a := range s.actions {
+			if nameOf(a) == name {
+				bits |= s.include[i]
+			}
+		}
+	}
+	return bits
+}
+
+// complete returns whether the node performed every required action, which is only
+// required once the final goal is reached.
+func (s *Search) complete(node *State) bool {
+	return node.included == s.require || s.segment < len(s.options.waypoints)
+}
+
+// keyOf returns the key of the state reached by performing the action after the parent
+// node in the visited set, telling apart the states which performed different required
+// actions, spent different amounts of the capped resources or took different risks.
+func (s *Search) keyOf(state, parent *State, action Action) uint32 {
+	key := s.heap.keyOf(state)
+	if state.included != 0 {
+		key ^= uint32(mix(state.included))
+	}
+
+	for i, limit := range s.options.caps {
+		if amount := spent(parent, limit.Name) + consumed(action, limit.Name); amount != 0 {
+			key ^= uint32(mix(uint64(math.Float32bits(amount))<<8 | uint64(i)))
+		}
+	}
+
+	if s.options.success > 0 {
+		if success := successOf(parent) * (1 - riskOf(action)); success < 1 {
+			key ^= uint32(mix(uint64(math.Float32bits(success))<<8 | 0xff))
+		}
+	}
+	return key
+}
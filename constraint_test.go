@@ -0,0 +1,55 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMustInclude(t *testing.T) {
+	start, goal := StateOf("A", "!gold"), StateOf("C")
+	actions := []Action{
+		move("A->B"), move("B->C"),
+		actionOf("Dig", 1, StateOf("B"), StateOf("gold")),
+		actionOf("Talk", 1, StateOf("C"), StateOf("talked")),
+	}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->C"}, planOf(plan))
+
+	// The scripted beat is performed along the way
+	plan, err = Plan(start, goal, actions, WithMustInclude("Dig"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "Dig", "B->C"}, planOf(plan))
+
+	// Even once the goal is reached
+	plan, err = Plan(start, goal, actions, WithMustInclude("Talk", "Dig"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "Dig", "B->C", "Talk"}, planOf(plan))
+
+	// Required actions which are not available are reported
+	_, err = Plan(start, goal, actions, WithMustInclude("Fly"))
+	assert.ErrorContains(t, err, "required action 'Fly' is not available")
+}
+
+func TestMustAvoid(t *testing.T) {
+	start, goal := StateOf("A"), StateOf("C")
+	actions := []Action{
+		move("A->B"), move("B->C"), move("A->C", 5),
+	}
+
+	plan, err := Plan(start, goal, actions, WithMustAvoid("B->C"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C"}, planOf(plan))
+
+	_, err = Plan(start, goal, actions, WithMustAvoid("A->C", "B->C"))
+	assert.Error(t, err)
+
+	// Both constraints can be combined
+	_, err = Plan(start, goal, actions, WithMustAvoid("A->B"), WithMustInclude("A->B"))
+	assert.Error(t, err)
+}
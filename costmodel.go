@@ -0,0 +1,67 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "math"
+
+// CostModel represents a learned model of the cost of the actions, for example one fitted
+// on recorded gameplay, which the planner consults for every action in every state it
+// expands instead of the declared cost of the action.
+type CostModel interface {
+
+	// Cost returns the estimated cost of performing the action in the state, and false if
+	// the model has no estimate for it, in which case the declared cost is used.
+	Cost(state *State, action Action) (float32, bool)
+}
+
+// HeuristicModel represents a cost model which also estimates the remaining cost to reach
+// the goal. A learned heuristic may overestimate, in which case plans are no longer
+// guaranteed to be optimal.
+type HeuristicModel interface {
+	CostModel
+
+	// Heuristic returns the estimated cost to reach the goal from the state, and false if
+	// the model has no estimate for it, in which case the distance to the goal is used.
+	Heuristic(state, goal *State) (float32, bool)
+}
+
+// WithCostModel consults the learned model for the cost of the actions, and for the
+// heuristic if it implements HeuristicModel. The declared cost and the distance to the
+// goal are used whenever the model has no estimate or returns a negative, infinite or
+// NaN one, so a partially trained model never breaks the search.
+func WithCostModel(model CostModel) Option {
+	return func(o *options) {
+		o.model = model
+	}
+}
+
+// learnedCost returns the cost of the action in the state estimated by the model, or by
+// the cost provider for the moves, falling back to the declared cost of the action.
+func learnedCost(o *options, state *State, action Action) float32 {
+	if o.model != nil {
+		if cost, ok := o.model.Cost(state, action); ok && estimated(cost) {
+			return cost
+		}
+	}
+	if cost, ok := movementCost(o.provider, action); ok {
+		return cost
+	}
+	return action.Cost()
+}
+
+// learnedDistance returns the estimated cost to reach the goal from the state, falling
+// back to the distance to the goal.
+func learnedDistance(model CostModel, goal objective, state *State) float32 {
+	if h, ok := model.(HeuristicModel); ok && goal.state != nil {
+		if cost, ok := h.Heuristic(state, goal.state); ok && estimated(cost) {
+			return cost
+		}
+	}
+	return goal.Distance(state)
+}
+
+// estimated returns whether the estimate of a model is usable by the search.
+func estimated(cost float32) bool {
+	return cost >= 0 && !math.IsInf(float64(cost), 0) && !math.IsNaN(float64(cost))
+}
@@ -0,0 +1,67 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCostModel(t *testing.T) {
+	actions := []Action{
+		move("A->B"), move("B->D"),
+		move("A->C", 2), move("C->D", 2),
+	}
+
+	plan, err := Plan(StateOf("A", "!B", "!C", "!D"), StateOf("D"), actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->D"}, planOf(plan))
+
+	// The recorded gameplay shows the path through B is much slower than declared
+	plan, err = Plan(StateOf("A", "!B", "!C", "!D"), StateOf("D"), actions, WithCostModel(learned{
+		"B->D": 500,
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C", "C->D"}, planOf(plan))
+
+	// Invalid estimates fall back to the declared cost
+	plan, err = Plan(StateOf("A", "!B", "!C", "!D"), StateOf("D"), actions, WithCostModel(learned{
+		"B->D": float32(math.NaN()),
+		"A->B": -1,
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->D"}, planOf(plan))
+}
+
+func TestHeuristicModel(t *testing.T) {
+	var counters Counters
+	model := &learnedHeuristic{learned: learned{}}
+	plan, err := Plan(StateOf("A", "!B", "!C", "!D"), StateOf("D"), []Action{
+		move("A->B"), move("B->D"), move("A->C"), move("C->D"),
+	}, WithCostModel(model), WithCounters(&counters))
+	assert.NoError(t, err)
+	assert.Len(t, plan, 2)
+	assert.Equal(t, counters.Distance, model.calls)
+}
+
+// learned represents a learned cost model, keyed by the name of the action.
+type learned map[string]float32
+
+func (m learned) Cost(_ *State, action Action) (float32, bool) {
+	cost, ok := m[nameOf(action)]
+	return cost, ok
+}
+
+// learnedHeuristic represents a learned model which also estimates the heuristic.
+type learnedHeuristic struct {
+	learned
+	calls uint64
+}
+
+func (m *learnedHeuristic) Heuristic(state, goal *State) (float32, bool) {
+	m.calls++
+	return 0, m.calls%2 == 0
+}
@@ -0,0 +1,147 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Recipe represents a crafting recipe, consuming some items to produce others, where the
+// count of every item is a numeric fact of the state, such as "wood=3". A recipe without
+// any input is a gathering recipe, such as chopping wood.
+type Recipe struct {
+	Name    string             // The name of the action, such as "craft plank"
+	Cost    float32            // The cost of the action
+	Inputs  map[string]float32 // The counts of the items consumed
+	Outputs map[string]float32 // The counts of the items produced
+	Tools   []string           // The items required but not consumed, such as "axe"
+}
+
+// Craft generates the craft and gather actions of the recipes. Every action requires at
+// least the count of each of its inputs and one of each of its tools, then consumes the
+// inputs and produces the outputs. It returns an error reporting all of the invalid
+// recipes at once.
+func Craft(recipes ...Recipe) ([]Action, error) {
+	var errs []error
+	actions := make([]Action, 0, len(recipes))
+	for _, r := range recipes {
+		action, err := r.action()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plan: invalid recipe '%s': %w", r.Name, err))
+			continue
+		}
+
+		actions = append(actions, action)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
+
+// action generates the action of the recipe.
+func (r *Recipe) action() (Action, error) {
+	if len(r.Outputs) == 0 {
+		return nil, errors.New("recipe has no output")
+	}
+
+	action := &definedAction{
+		name:    r.Name,
+		cost:    r.Cost,
+		require: newState(len(r.Tools) + len(r.Inputs)),
+		outcome: newState(len(r.Inputs) + len(r.Outputs)),
+	}
+
+	// Tools are required but not consumed, a tool also used as an input is consumed
+	for _, tool := range r.Tools {
+		if err := requireItem(action.require, tool, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, item := range sorted(r.Inputs) {
+		if err := requireItem(action.require, item, r.Inputs[item]); err != nil {
+			return nil, err
+		}
+		if _, ok := r.Outputs[item]; ok {
+			continue // Netted with the output below
+		}
+		if err := produceItem(action.outcome, item, opDecrement, r.Inputs[item]); err != nil {
+			return nil, err
+		}
+	}
+
+	// An item both consumed and produced, such as a catalyst, only changes by the difference
+	for _, item := range sorted(r.Outputs) {
+		if _, err := itemOf(item, r.Outputs[item]); err != nil {
+			return nil, err
+		}
+
+		var err error
+		switch net := r.Outputs[item] - r.Inputs[item]; {
+		case net > 0:
+			err = produceItem(action.outcome, item, opIncrement, net)
+		case net < 0:
+			err = produceItem(action.outcome, item, opDecrement, -net)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return action, nil
+}
+
+// requireItem requires at least the count of the item in the state.
+func requireItem(state *State, item string, count float32) error {
+	f, err := itemOf(item, count)
+	if err != nil {
+		return err
+	}
+
+	// At least n is expressed as more than n minus the smallest fixed-point step
+	return state.store(f, exprOfFixed(opGreater, fixedOf(float64(count))-1))
+}
+
+// produceItem adds the change of the count of the item to the outcome.
+func produceItem(state *State, item string, op operator, count float32) error {
+	f, err := itemOf(item, count)
+	if err != nil {
+		return err
+	}
+
+	return state.store(f, exprOfFixed(op, fixedOf(float64(count))))
+}
+
+// itemOf returns the fact of the item, validating its name and count.
+func itemOf(item string, count float32) (fact, error) {
+	rule, err := CompileRule(item)
+	switch {
+	case err != nil:
+		return 0, err
+	case rule.Expr().Operator() != opEqual || rule.Expr().Fixed() != MaxValue*scale:
+		return 0, factError(rule, fmt.Errorf("invalid item '%s'", item))
+	case count <= MinValue || count > MaxValue || math.IsNaN(float64(count)):
+		return 0, factError(rule, fmt.Errorf("%w, count of '%s' is %v", ErrValueRange, item, count))
+	case fixedOf(float64(count)) == 0:
+		return 0, factError(rule, fmt.Errorf("%w, count of '%s' is below the precision", ErrValueRange, item))
+	}
+
+	return rule.Fact(), nil
+}
+
+// sorted returns the items of the table in a deterministic order.
+func sorted(items map[string]float32) []string {
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,62 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCraft(t *testing.T) {
+	actions, err := Craft(
+		Recipe{Name: "chop wood", Cost: 2, Outputs: map[string]float32{"wood": 1}, Tools: []string{"axe"}},
+		Recipe{Name: "craft plank", Cost: 1, Inputs: map[string]float32{"wood": 1}, Outputs: map[string]float32{"plank": 2}},
+		Recipe{Name: "craft table", Cost: 1, Inputs: map[string]float32{"plank": 4}, Outputs: map[string]float32{"table": 1}, Tools: []string{"saw"}},
+	)
+	assert.NoError(t, err)
+	assert.Len(t, actions, 3)
+
+	desc := DescribeAction(actions[2])
+	assert.Equal(t, "craft table", desc.Name)
+	assert.Equal(t, "{saw>0.99, plank>3.99}", StateOfRules(desc.Require...).String())
+	assert.Equal(t, "{table+1, plank-4}", StateOfRules(desc.Outcome...).String())
+
+	plan, err := Plan(StateOf("axe", "saw", "!wood", "!plank", "!table"), StateOf("table=1"), actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"chop wood", "craft plank", "chop wood", "craft plank", "craft table"}, planOf(plan))
+
+	// Without an axe, no wood can be gathered
+	_, err = Plan(StateOf("!axe", "saw", "!wood", "!plank", "!table"), StateOf("table=1"), actions)
+	assert.Error(t, err)
+}
+
+func TestCraftNetted(t *testing.T) {
+	actions, err := Craft(
+		Recipe{Name: "brew", Cost: 1, Inputs: map[string]float32{"yeast": 1, "water": 2}, Outputs: map[string]float32{"yeast": 2, "ale": 1}},
+		Recipe{Name: "boil", Cost: 1, Inputs: map[string]float32{"water": 3}, Outputs: map[string]float32{"water": 1}},
+		Recipe{Name: "stir", Cost: 1, Inputs: map[string]float32{"ale": 1}, Outputs: map[string]float32{"ale": 1}},
+	)
+	assert.NoError(t, err)
+
+	// The items consumed and produced only change by the difference
+	assert.Equal(t, "{water-2, ale+1, yeast+1}", StateOfRules(DescribeAction(actions[0]).Outcome...).String())
+	assert.Equal(t, "{water-2}", StateOfRules(DescribeAction(actions[1]).Outcome...).String())
+	assert.Equal(t, "{}", StateOfRules(DescribeAction(actions[2]).Outcome...).String())
+	assert.Equal(t, "{ale>0.99}", StateOfRules(DescribeAction(actions[2]).Require...).String())
+}
+
+func TestCraftInvalid(t *testing.T) {
+	_, err := Craft(
+		Recipe{Name: "nothing"},
+		Recipe{Name: "too many", Outputs: map[string]float32{"wood": 200}},
+		Recipe{Name: "invalid", Outputs: map[string]float32{"wood>5": 1}},
+		Recipe{Name: "dust", Inputs: map[string]float32{"dust": 0.001}, Outputs: map[string]float32{"wood": 1}},
+	)
+	assert.ErrorContains(t, err, "recipe 'nothing': recipe has no output")
+	assert.ErrorContains(t, err, "recipe 'too many'")
+	assert.ErrorIs(t, err, ErrValueRange)
+	assert.ErrorContains(t, err, "invalid item 'wood>5'")
+	assert.ErrorContains(t, err, "recipe 'dust': plan: value is out of range, count of 'dust' is below the precision")
+}
@@ -0,0 +1,93 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "sort"
+
+// Blocker represents a precondition blocking the actions in the dead ends of the searches.
+type Blocker struct {
+	Rule  string // The unmet precondition, such as "food>0"
+	Count uint64 // Number of times the precondition blocked an action in a dead end
+}
+
+// DeadEnds represents the states reached during the searches from which no action is
+// applicable, along with the preconditions which blocked the actions there. The most
+// common blockers point domain authors to the missing actions. It accumulates the tallies
+// of every search it is passed to with WithDeadEnds and is not safe for concurrent use.
+type DeadEnds struct {
+	count   uint64          // Number of dead ends
+	rules   map[Rule]uint64 // Number of times every precondition blocked an action
+	pending []Rule          // The unmet preconditions of the node being expanded
+}
+
+// Count returns the number of dead ends reached.
+func (d *DeadEnds) Count() uint64 {
+	return d.count
+}
+
+// Blockers returns the preconditions which blocked the actions in the dead ends, from the
+// most to the least common one.
+func (d *DeadEnds) Blockers() []Blocker {
+	out := make([]Blocker, 0, len(d.rules))
+	for r, n := range d.rules {
+		out = append(out, Blocker{Rule: r.String(), Count: n})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Rule < out[j].Rule
+	})
+	return out
+}
+
+// Reset clears all of the tallies.
+func (d *DeadEnds) Reset() {
+	d.count = 0
+	d.pending = d.pending[:0]
+	clear(d.rules)
+}
+
+// begin starts the expansion of a node.
+func (d *DeadEnds) begin() {
+	if d != nil {
+		d.pending = d.pending[:0]
+	}
+}
+
+// block records the preconditions of the requirements unmet by the state.
+func (d *DeadEnds) block(state, require *State) {
+	if d == nil {
+		return
+	}
+
+	for _, r := range require.vx {
+		e, ok := state.lookup(r.Fact())
+		if !ok {
+			d.pending = append(d.pending, r)
+			continue
+		}
+
+		if match, err := satisfies(r.Fact(), r.Expr(), e); !match && err == nil {
+			d.pending = append(d.pending, r)
+		}
+	}
+}
+
+// end completes the expansion of a node, tallying its blockers if it is a dead end.
+func (d *DeadEnds) end(dead bool) {
+	if d == nil || !dead {
+		return
+	}
+
+	if d.rules == nil {
+		d.rules = make(map[Rule]uint64, 16)
+	}
+
+	d.count++
+	for _, r := range d.pending {
+		d.rules[r]++
+	}
+}
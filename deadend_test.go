@@ -0,0 +1,44 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadEnds(t *testing.T) {
+	var dead DeadEnds
+	_, err := Plan(StateOf("A", "!key"), StateOf("C"), []Action{
+		move("A->B"),
+		actionOf("Unlock", 1, StateOf("B", "key"), StateOf("!B", "C")),
+	}, WithDeadEnds(&dead))
+	assert.Error(t, err)
+
+	// Once in B, the missing key blocks the only way forward
+	assert.Equal(t, uint64(1), dead.Count())
+	assert.Equal(t, []Blocker{
+		{Rule: "A=100", Count: 1},
+		{Rule: "key=100", Count: 1},
+	}, dead.Blockers())
+
+	dead.Reset()
+	assert.Zero(t, dead.Count())
+	assert.Empty(t, dead.Blockers())
+}
+
+func TestAnalyzeBlockers(t *testing.T) {
+	out := Analyze([]*State{StateOf("A", "!key"), StateOf("B", "!key")}, StateOf("C"), []Action{
+		move("A->B"),
+		actionOf("Unlock", 1, StateOf("B", "key"), StateOf("!B", "C")),
+	})
+
+	assert.Equal(t, 0, out.Solved)
+	assert.Equal(t, []Blocker{
+		{Rule: "A=100", Count: 2},
+		{Rule: "key=100", Count: 2},
+	}, out.Blockers)
+	assert.Contains(t, out.String(), "blocked by key=100")
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// PlanDecomposed finds a plan to reach a goal over many independent facts by splitting it
+// into a subgoal per fact, planning each of them in turn from the state reached by the
+// previous ones and concatenating the sub-plans. The resource caps and the minimum success
+// apply to the whole plan, and the required actions are planned for once every fact is
+// reached, if not already included by the sub-plans. This keeps every search small instead of
+// exploring the combinations of all the facts at once, but the plan is only as good as the
+// sum of its parts. If any subgoal can't be planned, or a sub-plan undoes a fact achieved by
+// a previous one, it falls back to planning for the whole goal.
+func PlanDecomposed(start, goal *State, actions []Action, opts ...Option) ([]Action, error) {
+	return decompose(start, goal, actions, optionsOf(opts))
+}
+
+// PlanDecomposed finds a plan to reach the goal by planning every fact of the goal in turn,
+// falling back to planning for the whole goal if the sub-plans conflict.
+func (p *Planner) PlanDecomposed(start, goal *State, opts ...Option) ([]Action, error) {
+	return decompose(start, goal, p.actions, with(p.options, opts))
+}
+
+// decompose plans the subgoals in sequence, or the whole goal if they conflict.
+func decompose(start, goal *State, actions []Action, o options) ([]Action, error) {
+	// Only the plan returned is recorded, rather than the plan of every sub-search
+	analytics := o.analytics
+	o.analytics = nil
+
+	var plan []Action
+	var ok bool
+	if len(o.waypoints) == 0 {
+		plan, ok = sequence(start, goal, actions, o)
+	}
+
+	var err error
+	if !ok {
+		plan, err = run(nil, start, goalOf(goal), actions, o)
+	}
+
+	if err == nil {
+		analytics.record(plan)
+	}
+	return plan, err
+}
+
+// sequence plans every fact of the goal in turn and returns whether the concatenated plan
+// reaches the whole goal within the budget, if any.
+func sequence(start, goal *State, actions []Action, o options) ([]Action, bool) {
+	desired := goal.Flatten()
+	current := start.Clone()
+	final := newState(0)
+	defer desired.release()
+	defer current.release()
+	defer final.release()
+
+	// Every sub-search starts from the plan so far, which carries the amounts spent and the
+	// risks taken, but only the last one needs to include the required actions
+	var plan []Action
+	dst := o.final
+	o.final = final
+	last := o
+	o.include = nil
+	for _, rule := range desired.vx {
+		subgoal := StateOfRules(rule)
+		done, err := current.Match(subgoal)
+		if err != nil || done {
+			subgoal.release()
+			if err != nil {
+				return nil, false
+			}
+			continue
+		}
+
+		next, err := run(plan, current, goalOf(subgoal), actions, o)
+		subgoal.release()
+		if err != nil {
+			return nil, false
+		}
+
+		plan = next
+		current.copyFrom(final)
+	}
+
+	if len(last.include) > 0 {
+		next, err := run(plan, current, goalOf(desired), actions, last)
+		if err != nil {
+			return nil, false
+		}
+
+		plan = next
+		current.copyFrom(final)
+	}
+
+	// A later sub-plan may have undone a fact achieved by a previous one
+	if done, err := current.Match(desired); !done || err != nil {
+		return nil, false
+	}
+
+	if o.budget > 0 && costOf(plan) > o.budget {
+		return nil, false
+	}
+
+	if dst != nil {
+		dst.copyFrom(current)
+	}
+	return plan, true
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanDecomposed(t *testing.T) {
+	start, goal := StateOf(), StateOf()
+	actions := make([]Action, 0, 12)
+	for i := 0; i < 12; i++ {
+		name := "f" + strconv.Itoa(i)
+		start.Add("!" + name)
+		goal.Add(name)
+		actions = append(actions, actionOf("set "+name, 1, StateOf("!"+name), StateOf(name)))
+	}
+
+	var final State
+	plan, err := PlanDecomposed(start, goal, actions, WithFinal(&final))
+	assert.NoError(t, err)
+	assert.Len(t, plan, 12)
+
+	ok, err := final.Match(goal)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestPlanDecomposedConflict(t *testing.T) {
+	// Achieving either fact on its own undoes the other one
+	actions := []Action{
+		actionOf("wear hat", 1, StateOf("!hat"), StateOf("hat", "!coat")),
+		actionOf("wear coat", 1, StateOf("!coat"), StateOf("coat", "!hat")),
+		actionOf("wear both", 5, StateOf("!hat", "!coat"), StateOf("hat", "coat")),
+	}
+
+	plan, err := PlanDecomposed(StateOf("!hat", "!coat"), StateOf("hat", "coat"), actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"wear both"}, planOf(plan))
+}
+
+func TestPlanDecomposedConstraints(t *testing.T) {
+	gold := func(name string, cost, amount float32, require, outcome *State) Action {
+		return &resourceAction{
+			testAction: testAction{name: name, cost: cost, require: require, outcome: outcome},
+			resources:  []Resource{{Name: "gold", Amount: amount}},
+		}
+	}
+
+	risky := func(name string, risk float32, require, outcome *State) Action {
+		return &riskyAction{testAction: testAction{name: name, cost: 1, require: require, outcome: outcome}, risk: risk}
+	}
+
+	// Buying either item fits the cap on its own, but not both of them
+	plan, err := PlanDecomposed(StateOf("!hat", "!coat"), StateOf("hat", "coat"), []Action{
+		gold("buy hat", 1, 2, StateOf("!hat"), StateOf("hat")),
+		gold("buy coat", 1, 2, StateOf("!coat"), StateOf("coat")),
+		actionOf("sew hat", 3, StateOf("!hat"), StateOf("hat")),
+		actionOf("sew coat", 3, StateOf("!coat"), StateOf("coat")),
+	}, WithResourceCap("gold", 3))
+	assert.NoError(t, err)
+	assert.Len(t, plan, 2)
+	assert.LessOrEqual(t, ResourcesOf(plan)["gold"], float32(3))
+
+	// Taking either risk keeps the plan likely to succeed, but not both of them
+	plan, err = PlanDecomposed(StateOf("!hat", "!coat"), StateOf("hat", "coat"), []Action{
+		risky("steal hat", 0.3, StateOf("!hat"), StateOf("hat")),
+		risky("steal coat", 0.3, StateOf("!coat"), StateOf("coat")),
+		actionOf("sew hat", 3, StateOf("!hat"), StateOf("hat")),
+		actionOf("sew coat", 3, StateOf("!coat"), StateOf("coat")),
+	}, WithMinSuccess(0.6))
+	assert.NoError(t, err)
+	assert.Len(t, plan, 2)
+	assert.GreaterOrEqual(t, SuccessOf(plan), float32(0.6))
+
+	// The required action is performed once, after every fact is reached
+	plan, err = PlanDecomposed(StateOf("!hat", "!coat", "!danced"), StateOf("hat", "coat"), []Action{
+		actionOf("wear hat", 1, StateOf("!hat"), StateOf("hat")),
+		actionOf("wear coat", 1, StateOf("!coat"), StateOf("coat")),
+		actionOf("dance", 1, StateOf("!danced"), StateOf("danced")),
+	}, WithMustInclude("dance"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"wear hat", "wear coat", "dance"}, planOf(plan))
+}
@@ -0,0 +1,55 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// Description represents the structured description of an action, which allows tools
+// such as debuggers or exporters to render any domain without knowing concrete types.
+type Description struct {
+	Name    string   // The name of the action
+	Cost    float32  // The cost of the action
+	Require []Rule   // The requirements of the action
+	Outcome []Rule   // The outcomes of the action
+	Tags    []string // The optional tags of the action
+}
+
+// DescribedAction can be optionally implemented by actions which describe themselves,
+// as the actions provided by the library do.
+type DescribedAction interface {
+
+	// Describe returns the structured description of the action.
+	Describe() Description
+}
+
+// TaggedAction can be optionally implemented by actions carrying tags, such as "loud"
+// or "combat", which are reported in their description.
+type TaggedAction interface {
+
+	// Tags returns the tags of the action.
+	Tags() []string
+}
+
+// DescribeAction returns the structured description of any action. Actions which do not
+// describe themselves are simulated against an empty state, hence for actions that depend
+// on the current state the description is only indicative.
+func DescribeAction(action Action) Description {
+	if d, ok := action.(DescribedAction); ok {
+		return d.Describe()
+	}
+
+	require, outcome := describe(action, nil)
+	defer require.release()
+	defer outcome.release()
+
+	desc := Description{
+		Name:    nameOf(action),
+		Cost:    action.Cost(),
+		Require: require.Rules(),
+		Outcome: outcome.Rules(),
+	}
+
+	if t, ok := action.(TaggedAction); ok {
+		desc.Tags = t.Tags()
+	}
+	return desc
+}
@@ -0,0 +1,46 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// Conditional represents a conditional effect of an action, applying different effects
+// depending on whether a condition holds in the current state, such as "if has_axe then
+// wood+20 else wood+5". This avoids an explosion of near-duplicate actions.
+type Conditional struct {
+	If   *State // The condition which must hold in the current state
+	Then *State // The effects applied if the condition holds
+	Else *State // The effects applied otherwise, if any
+}
+
+// OutcomeOf returns the outcome of an action, given the current state, made of the
+// unconditional effects followed by the conditional effects which apply. It is meant
+// to be called from Simulate(), which receives the current state. The unconditional
+// effects can be nil, and an invalid current state never satisfies any condition.
+func OutcomeOf(current, effects *State, conditionals ...Conditional) *State {
+	outcome := newState(0)
+	OutcomeInto(outcome, current, effects, conditionals...)
+	return outcome
+}
+
+// OutcomeInto writes the outcome of an action into the destination state, like OutcomeOf,
+// which allows to use conditional effects from SimulateInto() without any allocations.
+func OutcomeInto(dst, current, effects *State, conditionals ...Conditional) {
+	if effects != nil {
+		for _, r := range effects.vx {
+			dst.AddRule(r)
+		}
+	}
+
+	for _, c := range conditionals {
+		apply := c.Else
+		if ok, err := current.Match(c.If); ok && err == nil {
+			apply = c.Then
+		}
+
+		if apply != nil {
+			for _, r := range apply.vx {
+				dst.AddRule(r)
+			}
+		}
+	}
+}
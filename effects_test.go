@@ -0,0 +1,53 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutcomeOf(t *testing.T) {
+	chop := Conditional{
+		If:   StateOf("has_axe"),
+		Then: StateOf("wood+20"),
+		Else: StateOf("wood+5"),
+	}
+
+	outcome := OutcomeOf(StateOf("has_axe"), StateOf("tired+10"), chop)
+	assert.Equal(t, StateOf("tired+10", "wood+20").Hash(), outcome.Hash())
+
+	outcome = OutcomeOf(StateOf("!has_axe"), StateOf("tired+10"), chop)
+	assert.Equal(t, StateOf("tired+10", "wood+5").Hash(), outcome.Hash())
+
+	// The conditional effects override the unconditional ones
+	outcome = OutcomeOf(StateOf("has_axe"), StateOf("wood+1"), chop, Conditional{
+		If:   StateOf("has_axe"),
+		Then: StateOf("axe-10"),
+	})
+	assert.Equal(t, StateOf("wood+20", "axe-10").Hash(), outcome.Hash())
+
+	// With an axe, two chops are enough, without it a plan needs 4 of them
+	actions := []Action{&chopAction{chop: chop}}
+	plan, err := Plan(StateOf("has_axe", "!wood"), StateOf("wood>30"), actions)
+	assert.NoError(t, err)
+	assert.Len(t, plan, 2)
+
+	plan, err = Plan(StateOf("!has_axe", "wood=10"), StateOf("wood>25"), actions)
+	assert.NoError(t, err)
+	assert.Len(t, plan, 4)
+}
+
+type chopAction struct {
+	chop Conditional
+}
+
+func (a *chopAction) Simulate(current *State) (*State, *State) {
+	return StateOf(), OutcomeOf(current, nil, a.chop)
+}
+
+func (a *chopAction) Cost() float32 {
+	return 1
+}
@@ -0,0 +1,95 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+// Package eventbus publishes the lifecycle events of the planner and the executor through
+// the topics of github.com/kelindar/event, so games already using that bus get telemetry
+// of their agents without any custom glue.
+package eventbus
+
+import (
+	"github.com/kelindar/event"
+	"github.com/kelindar/goap"
+)
+
+// Types of the events published on the bus. They are chosen in a range unlikely to
+// conflict with the event types of the application.
+const (
+	TypePlanFound uint32 = 0x60A90000 + iota
+	TypeActionFailed
+	TypeGoalChanged
+	TypeGoalReached
+	TypeGoalAbandoned
+)
+
+// PlanFound is published when the executor finds a new plan for its goal.
+type PlanFound struct {
+	Goal *goap.State
+	Plan []goap.Action
+}
+
+// Type returns the type of the event.
+func (PlanFound) Type() uint32 { return TypePlanFound }
+
+// ActionFailed is published when an action failed for good, after its retries.
+type ActionFailed struct {
+	Goal   *goap.State
+	Action goap.Action
+	Err    error
+}
+
+// Type returns the type of the event.
+func (ActionFailed) Type() uint32 { return TypeActionFailed }
+
+// GoalChanged is published when the goal of the executor is changed by a reload.
+type GoalChanged struct {
+	Goal *goap.State
+}
+
+// Type returns the type of the event.
+func (GoalChanged) Type() uint32 { return TypeGoalChanged }
+
+// GoalReached is published when the plan of the executor was fully performed.
+type GoalReached struct {
+	Goal *goap.State
+}
+
+// Type returns the type of the event.
+func (GoalReached) Type() uint32 { return TypeGoalReached }
+
+// GoalAbandoned is published when the executor gives up on its goal.
+type GoalAbandoned struct {
+	Goal *goap.State
+	Err  error
+}
+
+// Type returns the type of the event.
+func (GoalAbandoned) Type() uint32 { return TypeGoalAbandoned }
+
+// Publish returns a handler of the lifecycle events of an executor, to be passed to its
+// Notify method, which publishes them on the dispatcher, or on the default dispatcher of
+// the event package if nil.
+func Publish(dispatcher *event.Dispatcher) func(goap.Event) {
+	return func(ev goap.Event) {
+		switch ev.Kind {
+		case goap.EventPlanFound:
+			emit(dispatcher, PlanFound{Goal: ev.Goal, Plan: ev.Plan})
+		case goap.EventActionFailed:
+			emit(dispatcher, ActionFailed{Goal: ev.Goal, Action: ev.Action, Err: ev.Err})
+		case goap.EventGoalChanged:
+			emit(dispatcher, GoalChanged{Goal: ev.Goal})
+		case goap.EventGoalReached:
+			emit(dispatcher, GoalReached{Goal: ev.Goal})
+		case goap.EventGoalAbandoned:
+			emit(dispatcher, GoalAbandoned{Goal: ev.Goal, Err: ev.Err})
+		}
+	}
+}
+
+// emit publishes the event on the dispatcher, or on the default one if nil.
+func emit[T event.Event](dispatcher *event.Dispatcher, ev T) {
+	if dispatcher == nil {
+		event.Emit(ev)
+		return
+	}
+	event.Publish(dispatcher, ev)
+}
@@ -0,0 +1,47 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package eventbus
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/kelindar/event"
+	"github.com/kelindar/goap"
+	"github.com/kelindar/goap/goaptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublish(t *testing.T) {
+	dispatcher := event.NewDispatcher()
+	defer dispatcher.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	var found PlanFound
+	var failed ActionFailed
+	var abandoned GoalAbandoned
+	defer event.Subscribe(dispatcher, func(ev PlanFound) { found = ev; wg.Done() })()
+	defer event.Subscribe(dispatcher, func(ev ActionFailed) { failed = ev; wg.Done() })()
+	defer event.Subscribe(dispatcher, func(ev GoalAbandoned) { abandoned = ev; wg.Done() })()
+
+	jump := &goaptest.Action{Name: "Jump", Weight: 1, Require: goap.StateOf("A"), Outcome: goap.StateOf("B")}
+	planner, err := goap.NewPlanner([]goap.Action{jump})
+	assert.NoError(t, err)
+
+	executor := goap.NewExecutor(planner, goap.RetryPolicy{Recovery: goap.RecoveryAbandon})
+	executor.Notify(Publish(dispatcher))
+	err = executor.Run(func() *goap.State { return goap.StateOf("A") }, goap.StateOf("B"), func(goap.Action) error {
+		return errors.New("slipped")
+	})
+	assert.ErrorIs(t, err, goap.ErrAbandoned)
+
+	wg.Wait()
+	assert.Equal(t, []goap.Action{jump}, found.Plan)
+	assert.Equal(t, jump, failed.Action)
+	assert.ErrorContains(t, failed.Err, "slipped")
+	assert.ErrorIs(t, abandoned.Err, goap.ErrAbandoned)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/kelindar/goap"
+)
+
+// spent is the outcome of a missed shot.
+var spent = goap.StateOf("ammo-1")
+
+func main() {
+	actions := []goap.Action{
+		NewAction("patrol", 1, 0, "!enemy,!noise", "patrolled+25"),
+		NewAction("walk to noise", 2, 0, "noise,!at_noise", "at_noise"),
+		NewAction("search area", 1, 0, "at_noise", "!noise,!at_noise"),
+		NewAction("draw weapon", 1, 0, "!armed", "armed"),
+		NewAction("reload", 2, 0, "armed,ammo<1", "ammo=3"),
+		NewAction("shoot", 1, 0.5, "enemy,armed,ammo>0", "!enemy,ammo-1"),
+		NewAction("melee", 200, 0, "enemy", "!enemy"), // Last resort, once out of ammo
+	}
+
+	planner, err := goap.NewPlanner(actions)
+	if err != nil {
+		panic(err)
+	}
+
+	guard := NewGuard(planner)
+	for guard.tick < 20 {
+		if !guard.Think() {
+			break
+		}
+	}
+}
+
+// ------------------------------------ Guard ------------------------------------
+
+// Guard represents an agent guarding an area. It patrols, investigates the noises it
+// hears and attacks the enemies it sees, the most important goal being pursued first.
+type Guard struct {
+	planner *goap.Planner
+	arbiter *goap.Arbiter
+	world   *goap.State
+	goals   []goap.Candidate
+	goal    goap.Candidate
+	dice    *rand.Rand
+	tick    int
+}
+
+// NewGuard creates a new guard which plans with the planner.
+func NewGuard(planner *goap.Planner) *Guard {
+	return &Guard{
+		planner: planner,
+		arbiter: goap.NewArbiter(0.1, 0.1),
+		world:   goap.StateOf("!patrolled", "!noise", "!at_noise", "!enemy", "!armed", "ammo=2"),
+		dice:    rand.New(rand.NewSource(42)),
+		goals: []goap.Candidate{
+			{Name: "patrol", Goal: goap.StateOf("patrolled=100"), Priority: 1},
+			{Name: "investigate", Goal: goap.StateOf("!noise"), Priority: 5},
+			{Name: "attack", Goal: goap.StateOf("!enemy"), Priority: 10},
+		},
+	}
+}
+
+// Think selects the most important goal and pursues it until it is reached, abandoned or
+// interrupted by a more important goal. It returns false if there is nothing left to do
+// or if the guard gave up.
+func (g *Guard) Think() bool {
+	goal, ok := g.arbiter.Select(g.world, g.goals)
+	if !ok {
+		return false
+	}
+
+	// A new executor is used for every goal, since interruptions override its goal
+	g.goal = goal
+	executor := goap.NewExecutor(g.planner, goap.RetryPolicy{Retries: 1, MaxReplans: 3})
+	executor.Notify(g.log)
+
+	fmt.Printf("[%2d] pursuing '%s'\n", g.tick, goal.Name)
+	err := executor.Run(g.sense, goal.Goal, func(action goap.Action) error {
+		return g.perform(executor, action.(*Action))
+	})
+
+	switch {
+	case err != nil:
+		fmt.Printf("[%2d] gave up on '%s'\n", g.tick, g.goal.Name)
+		return false
+	case g.goal.Name == "patrol":
+		g.world.Add("patrolled=0") // Start the next round
+		g.arbiter.Complete(g.goal.Name)
+	}
+	return true
+}
+
+// sense returns the state of the world as perceived by the guard.
+func (g *Guard) sense() *goap.State {
+	return g.world.Clone()
+}
+
+// perform performs the action in the world, which may fail for the risky actions, and
+// then lets the world evolve. If a more important goal appears, the executor is reloaded
+// with it, abandoning the current plan.
+func (g *Guard) perform(executor *goap.Executor, action *Action) error {
+	g.tick++
+	if action.risk > 0 && g.dice.Float32() < action.risk {
+		fmt.Printf("[%2d]   %s failed\n", g.tick, action)
+		if action.name == "shoot" { // The bullet is spent regardless
+			if err := g.world.Apply(spent); err != nil {
+				return err
+			}
+		}
+		return errors.New(action.name + " failed")
+	}
+
+	fmt.Printf("[%2d]   %s\n", g.tick, action)
+	if err := g.world.ApplyAction(action, action.outcome); err != nil {
+		return err
+	}
+
+	g.update()
+	if next := g.urgent(); next.Name != g.goal.Name {
+		fmt.Printf("[%2d] interrupted by '%s'\n", g.tick, next.Name)
+		g.goal = next
+		executor.Reload(nil, next.Goal)
+	}
+	return nil
+}
+
+// update lets the world evolve, as scripted for this example.
+func (g *Guard) update() {
+	switch g.tick {
+	case 3:
+		fmt.Printf("[%2d] * the guard hears a noise\n", g.tick)
+		g.world.Add("noise")
+	case 6:
+		fmt.Printf("[%2d] * an intruder shows up\n", g.tick)
+		g.world.Add("enemy")
+	}
+}
+
+// urgent returns the most important goal which is not yet achieved, without making the
+// guard bored of it.
+func (g *Guard) urgent() goap.Candidate {
+	best := g.goal
+	for _, c := range g.goals {
+		if ok, _ := g.world.Match(c.Goal); !ok && g.arbiter.Priority(c) > g.arbiter.Priority(best) {
+			best = c
+		}
+	}
+	return best
+}
+
+// log prints the lifecycle events of the executor.
+func (g *Guard) log(ev goap.Event) {
+	switch ev.Kind {
+	case goap.EventPlanFound:
+		names := make([]string, 0, len(ev.Plan))
+		for _, action := range ev.Plan {
+			names = append(names, action.(*Action).String())
+		}
+		fmt.Printf("[%2d] plan: %s\n", g.tick, strings.Join(names, " -> "))
+	case goap.EventGoalReached:
+		fmt.Printf("[%2d] done with '%s'\n", g.tick, g.nameOf(ev.Goal))
+	case goap.EventActionFailed, goap.EventGoalAbandoned:
+		fmt.Printf("[%2d] %s: %v\n", g.tick, ev.Kind, ev.Err)
+	}
+}
+
+// nameOf returns the name of the goal.
+func (g *Guard) nameOf(goal *goap.State) string {
+	for _, c := range g.goals {
+		if c.Goal == goal {
+			return c.Name
+		}
+	}
+	return "unknown"
+}
+
+// ------------------------------------ Action ------------------------------------
+
+// NewAction creates a new action from the given name, cost, risk of failing, require
+// and outcome.
+func NewAction(name string, cost, risk float32, require, outcome string) *Action {
+	return &Action{
+		name:    name,
+		cost:    cost,
+		risk:    risk,
+		require: goap.StateOf(strings.Split(require, ",")...),
+		outcome: goap.StateOf(strings.Split(outcome, ",")...),
+	}
+}
+
+// Action represents a single action that can be performed by the guard.
+type Action struct {
+	name    string
+	cost    float32
+	risk    float32
+	require *goap.State
+	outcome *goap.State
+}
+
+// Simulate simulates the action and returns the required and outcome states.
+func (a *Action) Simulate(current *goap.State) (*goap.State, *goap.State) {
+	return a.require, a.outcome
+}
+
+// Cost returns the cost of the action.
+func (a *Action) Cost() float32 {
+	return a.cost
+}
+
+// Risk returns the probability of the action failing.
+func (a *Action) Risk() float32 {
+	return a.risk
+}
+
+// String returns the name of the action.
+func (a *Action) String() string {
+	return a.name
+}
@@ -0,0 +1,247 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// ErrAbandoned is returned by the executor when it gives up on the goal.
+var ErrAbandoned = errors.New("plan: goal abandoned")
+
+// errReloaded is returned by perform when the domain was reloaded during the execution.
+var errReloaded = errors.New("plan: domain reloaded")
+
+// defaultReplans is the maximum number of replans of a policy which doesn't specify any.
+const defaultReplans = 3
+
+// Recovery represents what the executor does once an action failed for good.
+type Recovery uint8
+
+const (
+	RecoveryReplan  Recovery = iota // Sense the world again and find a new plan (default)
+	RecoveryAbandon                 // Abandon the goal and return an ErrAbandoned error
+)
+
+// EventKind represents the kind of a lifecycle event of the executor.
+type EventKind uint8
+
+const (
+	EventPlanFound     EventKind = iota // A new plan was found for the goal
+	EventActionFailed                   // An action failed for good, after its retries
+	EventGoalChanged                    // The goal was changed by a reload
+	EventGoalReached                    // The plan was fully performed
+	EventGoalAbandoned                  // The goal was abandoned
+)
+
+// String returns the string representation of the event kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventPlanFound:
+		return "plan found"
+	case EventActionFailed:
+		return "action failed"
+	case EventGoalChanged:
+		return "goal changed"
+	case EventGoalReached:
+		return "goal reached"
+	case EventGoalAbandoned:
+		return "goal abandoned"
+	default:
+		return "unknown"
+	}
+}
+
+// Event represents a lifecycle event of the executor, which can be used for telemetry.
+type Event struct {
+	Kind   EventKind // The kind of the event
+	Goal   *State    // The goal being pursued
+	Plan   []Action  // The plan found, for the EventPlanFound events
+	Action Action    // The action which failed, for the EventActionFailed events
+	Err    error     // The error of the action or the goal, if any
+}
+
+// PerformableAction can be optionally implemented by actions which know how to perform
+// themselves in the world, as the execution-facing extension of an Action. The planner
+// itself only relies on the Action interface.
+type PerformableAction interface {
+	Action
+
+	// Perform performs the action in the world, returning an error if the action failed.
+	Perform() error
+}
+
+// Perform performs the action if it is a PerformableAction, and returns an error otherwise.
+// It can be passed as the perform function of an executor when every action of the domain
+// performs itself.
+func Perform(action Action) error {
+	if p, ok := action.(PerformableAction); ok {
+		return p.Perform()
+	}
+	return &ActionError{Action: nameOf(action), Err: fmt.Errorf("plan: action '%s' cannot be performed", nameOf(action))}
+}
+
+// RetryPolicy represents how the executor handles the failures of the actions.
+type RetryPolicy struct {
+	Retries    int      // Number of retries of a failed risky action, before recovering
+	Recovery   Recovery // What to do once the action failed for good
+	MaxReplans int      // Maximum number of replans before abandoning the goal, 3 if zero
+}
+
+// replans returns the maximum number of replans before abandoning the goal.
+func (p *RetryPolicy) replans() int {
+	if p.MaxReplans <= 0 {
+		return defaultReplans
+	}
+	return p.MaxReplans
+}
+
+// Executor executes the plans found by a planner, handling the failures of the actions
+// according to a retry policy. Only the risky actions, for which SuccessProbability() is
+// below 1, are retried as their failure is expected, while the failure of other actions
+// means the world has changed and directly triggers the recovery.
+type Executor struct {
+	lock    sync.Mutex
+	planner *Planner     // The planner used to find the plans
+	goal    *State       // The goal set by Reload, overriding the one given to Run
+	version uint64       // The version of the domain, incremented on every reload
+	policy  RetryPolicy  // The retry policy
+	notify  func(Event)  // The handler of the lifecycle events, if any
+	logger  *slog.Logger // The logger of the lifecycle events, if any
+}
+
+// NewExecutor creates a new executor for the planner and the retry policy.
+func NewExecutor(planner *Planner, policy RetryPolicy) *Executor {
+	return &Executor{
+		planner: planner,
+		policy:  policy,
+	}
+}
+
+// Run plans and performs the actions required to reach the goal. The sense function
+// returns the current state of the world, and is called before every (re)planning, while
+// the perform function performs a single action in the world, returning an error if the
+// action failed. It returns once the plan is fully performed, or the goal abandoned.
+func (e *Executor) Run(sense func() *State, goal *State, perform func(Action) error) error {
+	for replans := 0; ; {
+		planner, target, version := e.domain(goal)
+		plan, err := planner.Plan(sense(), target)
+		if err != nil {
+			e.emit(Event{Kind: EventGoalAbandoned, Goal: target, Err: err})
+			return err
+		}
+
+		e.emit(Event{Kind: EventPlanFound, Goal: target, Plan: plan})
+		failed, err := e.perform(plan, version, perform)
+		switch {
+		case err == nil:
+			e.emit(Event{Kind: EventGoalReached, Goal: target})
+			return nil
+		case errors.Is(err, errReloaded):
+			continue // The plan is stale, but nothing failed
+		}
+
+		e.emit(Event{Kind: EventActionFailed, Goal: target, Action: failed, Err: err})
+		if e.policy.Recovery == RecoveryAbandon || replans >= e.policy.replans() {
+			err = &ActionError{Action: nameOf(failed), Err: fmt.Errorf("%w, action '%s' failed: %w", ErrAbandoned, nameOf(failed), err)}
+			e.emit(Event{Kind: EventGoalAbandoned, Goal: target, Err: err})
+			return err
+		}
+		replans++
+		logDebug(e.currentLogger(), "plan: replan triggered", slog.Int("replans", replans))
+	}
+}
+
+// Reload swaps the planner and the goal used by the executor, which is safe to call while
+// it runs, for example after the actions of an ActionSet changed. A nil planner or goal
+// keeps the current one. The plan being executed is abandoned before its next action and
+// a new plan is found, without counting as a replan.
+func (e *Executor) Reload(planner *Planner, goal *State) {
+	e.lock.Lock()
+	if planner != nil {
+		e.planner = planner
+	}
+	if goal != nil {
+		e.goal = goal
+	}
+	e.version++
+	e.lock.Unlock()
+
+	if goal != nil {
+		e.emit(Event{Kind: EventGoalChanged, Goal: goal})
+	}
+}
+
+// Notify sets the handler called synchronously on every lifecycle event of the executor,
+// such as a plan being found or an action failing, replacing the previous one.
+func (e *Executor) Notify(handler func(Event)) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.notify = handler
+}
+
+// emit calls the handler of the lifecycle events, if any.
+func (e *Executor) emit(ev Event) {
+	e.log(ev)
+	e.lock.Lock()
+	notify := e.notify
+	e.lock.Unlock()
+	if notify != nil {
+		notify(ev)
+	}
+}
+
+// Version returns the version of the domain of the executor, which is incremented on
+// every reload.
+func (e *Executor) Version() uint64 {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.version
+}
+
+// domain returns the current planner, goal and version of the executor.
+func (e *Executor) domain(goal *State) (*Planner, *State, uint64) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.goal != nil {
+		goal = e.goal
+	}
+	return e.planner, goal, e.version
+}
+
+// perform performs the actions of the plan, retrying the risky actions. It returns the
+// action which failed for good along with its error, if any, or an errReloaded error if
+// the domain was reloaded since the plan was found, including while its last action was
+// performed, since the goal reached may no longer be the current one.
+func (e *Executor) perform(plan []Action, version uint64, perform func(Action) error) (Action, error) {
+	for _, action := range plan {
+		if e.Version() != version {
+			return action, errReloaded
+		}
+
+		retries := 0
+		if SuccessProbability(action) < 1 {
+			retries = e.policy.Retries
+		}
+
+		var err error
+		for attempt := 0; attempt <= retries; attempt++ {
+			if err = perform(action); err == nil {
+				break
+			}
+		}
+
+		if err != nil {
+			return action, err
+		}
+	}
+
+	if e.Version() != version {
+		return nil, errReloaded
+	}
+	return nil, nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutor(t *testing.T) {
+	jump := &riskyAction{testAction: *move("A->C").(*testAction), risk: 0.5}
+	planner, err := NewPlanner([]Action{jump, move("A->B", 2), move("B->C", 2)})
+	assert.NoError(t, err)
+
+	// The risky action is retried until it succeeds
+	world, failures := StateOf("A"), 2
+	err = NewExecutor(planner, RetryPolicy{Retries: 3}).Run(func() *State { return world.Clone() }, StateOf("C"), func(a Action) error {
+		if a == jump && failures > 0 {
+			failures--
+			return errors.New("slipped")
+		}
+		return world.Apply(a.(*riskyAction).outcome)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, failures)
+
+	// Once out of retries, the goal is abandoned
+	err = NewExecutor(planner, RetryPolicy{Retries: 1, Recovery: RecoveryAbandon}).Run(func() *State { return StateOf("A") }, StateOf("C"), func(a Action) error {
+		return errors.New("slipped")
+	})
+	assert.ErrorIs(t, err, ErrAbandoned)
+	assert.ErrorContains(t, err, "slipped")
+}
+
+func TestExecutorReplan(t *testing.T) {
+	jump := &riskyAction{
+		testAction: testAction{name: "Jump", cost: 1, require: StateOf("A", "bridge"), outcome: StateOf("!A", "C")},
+		risk:       0.5,
+	}
+
+	planner, err := NewPlanner([]Action{jump, move("A->B", 2), move("B->C", 2)})
+	assert.NoError(t, err)
+
+	// The bridge collapses when the jump fails, so the long way is taken instead
+	world := StateOf("A", "bridge")
+	var performed []string
+	err = NewExecutor(planner, RetryPolicy{MaxReplans: 1}).Run(func() *State { return world.Clone() }, StateOf("C"), func(a Action) error {
+		performed = append(performed, a.(fmt.Stringer).String())
+		if a == jump {
+			assert.NoError(t, world.Del("bridge"))
+			return errors.New("bridge collapsed")
+		}
+
+		_, outcome := a.Simulate(world)
+		return world.Apply(outcome)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Jump", "A->B", "B->C"}, performed)
+
+	// The default policy replans as well
+	world, performed = StateOf("A", "bridge"), nil
+	err = NewExecutor(planner, RetryPolicy{}).Run(func() *State { return world.Clone() }, StateOf("C"), func(a Action) error {
+		performed = append(performed, a.(fmt.Stringer).String())
+		if a == jump {
+			assert.NoError(t, world.Del("bridge"))
+			return errors.New("bridge collapsed")
+		}
+
+		_, outcome := a.Simulate(world)
+		return world.Apply(outcome)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Jump", "A->B", "B->C"}, performed)
+}
+
+func TestExecutorReload(t *testing.T) {
+	set, err := NewActionSet(move("A->B"), move("B->C"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), set.Version())
+
+	planner, err := set.Planner()
+	assert.NoError(t, err)
+
+	// A shortcut is added to the domain while the agent is on its way
+	world := StateOf("A")
+	executor := NewExecutor(planner, RetryPolicy{})
+	var performed []string
+	err = executor.Run(func() *State { return world.Clone() }, StateOf("C"), func(a Action) error {
+		performed = append(performed, a.(fmt.Stringer).String())
+		if len(performed) == 1 {
+			assert.NoError(t, set.Define("B->D", 1, []string{"B"}, []string{"!B", "D"}))
+			next, err := set.Planner()
+			assert.NoError(t, err)
+			executor.Reload(next, StateOf("D"))
+		}
+
+		_, outcome := a.Simulate(world)
+		return world.Apply(outcome)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->D"}, performed)
+	assert.Equal(t, uint64(2), set.Version())
+	assert.Equal(t, uint64(1), executor.Version())
+
+	assert.True(t, set.Remove("B->D"))
+	assert.Equal(t, uint64(3), set.Version())
+}
+
+func TestExecutorReloadLast(t *testing.T) {
+	planner, err := NewPlanner([]Action{move("A->B"), move("B->C")})
+	assert.NoError(t, err)
+
+	// The goal changes while the last action of the plan is being performed
+	world := StateOf("A")
+	executor := NewExecutor(planner, RetryPolicy{})
+	var performed []string
+	err = executor.Run(func() *State { return world.Clone() }, StateOf("B"), func(a Action) error {
+		performed = append(performed, a.(fmt.Stringer).String())
+		if len(performed) == 1 {
+			executor.Reload(nil, StateOf("C"))
+		}
+
+		_, outcome := a.Simulate(world)
+		return world.Apply(outcome)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->C"}, performed)
+	assert.Equal(t, uint64(1), executor.Version())
+}
+
+func TestPerform(t *testing.T) {
+	world := StateOf("A")
+	walk := &performAction{testAction: *move("A->B").(*testAction), world: world}
+	planner, err := NewPlanner([]Action{walk})
+	assert.NoError(t, err)
+
+	err = NewExecutor(planner, RetryPolicy{}).Run(func() *State { return world.Clone() }, StateOf("B"), Perform)
+	assert.NoError(t, err)
+	assert.Equal(t, StateOf("!A", "B").Hash(), world.Hash())
+	assert.ErrorContains(t, Perform(move("B->C")), "action 'B->C' cannot be performed")
+}
+
+type performAction struct {
+	testAction
+	world *State
+}
+
+func (a *performAction) Perform() error {
+	return a.world.Apply(a.outcome)
+}
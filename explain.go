@@ -0,0 +1,158 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Choice represents an action applicable at a step of a plan, along with its cost and the
+// estimated distance to the goal once it is performed, which together rank the actions.
+type Choice struct {
+	Action    Action  // The action
+	Cost      float32 // The cost of the action
+	Heuristic float32 // The estimated distance to the goal after performing the action
+}
+
+// Score returns the cost of the action plus the estimated distance to the goal after it.
+func (c Choice) Score() float32 {
+	return c.Cost + c.Heuristic
+}
+
+// Explanation represents why an action was chosen at a step of a plan, among the other
+// actions which were applicable at that step.
+type Explanation struct {
+	Step         int      // The index of the step in the plan, starting at 0
+	Chosen       Choice   // The action chosen by the planner
+	Alternatives []Choice // The other applicable actions, which were not chosen
+}
+
+// String returns a human-readable explanation of the step, with the cost and heuristic
+// deltas of every alternative relative to the chosen action.
+func (e Explanation) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d. %s (cost=%v, heuristic=%v)\n", e.Step+1, nameOf(e.Chosen.Action),
+		e.Chosen.Cost, e.Chosen.Heuristic)
+	for _, alt := range e.Alternatives {
+		fmt.Fprintf(&sb, "   - %s (cost %+g, heuristic %+g)\n", nameOf(alt.Action),
+			alt.Cost-e.Chosen.Cost, alt.Heuristic-e.Chosen.Heuristic)
+	}
+	return sb.String()
+}
+
+// ExplainPlan explains every step of the plan found for the goal, by listing the other
+// actions which were applicable at that step along with their cost and the estimated
+// distance to the goal they lead to. The heuristic only explains the local choice, since
+// an alternative which looks better may lead to a dead end or a costlier plan later on.
+func ExplainPlan(start, goal *State, plan []Action, actions []Action) ([]Explanation, error) {
+	current := start.Clone()
+	require, outcome := StateOf(), StateOf()
+	defer current.release()
+	defer require.release()
+	defer outcome.release()
+
+	out := make([]Explanation, 0, len(plan))
+	for i, chosen := range plan {
+		e := Explanation{Step: i}
+		for _, action := range actions {
+			choice, ok, err := choose(current, goal, action, require, outcome)
+			switch {
+			case err != nil:
+				return nil, &StepError{Step: i, Action: action, Err: err}
+			case !ok:
+				continue
+			case action == chosen:
+				e.Chosen = choice
+			default:
+				e.Alternatives = append(e.Alternatives, choice)
+			}
+		}
+
+		// Move on to the next step, which also validates the chosen action
+		if err := simulateStep(current, goal, i, chosen, require, outcome, OverflowSaturate, StanceExpected); err != nil {
+			return nil, err
+		}
+
+		if e.Chosen.Action == nil {
+			e.Chosen = Choice{Action: chosen, Cost: chosen.Cost(), Heuristic: current.Distance(goal)}
+		}
+		out = append(out, e)
+	}
+
+	return out, nil
+}
+
+// choose evaluates the action if it is applicable in the current state.
+func choose(current, goal *State, action Action, require, outcome *State) (Choice, bool, error) {
+	r, o := simulate(action, current, goal, require, outcome)
+	if ok, err := current.Match(r); !ok || err != nil {
+		return Choice{}, false, err
+	}
+
+	if ok, err := available(current, action); !ok || err != nil {
+		return Choice{}, false, err
+	}
+
+	next := current.Clone()
+	defer next.release()
+	if err := next.Apply(o); err != nil {
+		return Choice{}, false, err
+	}
+
+	if err := advance(next, action, OverflowSaturate); err != nil {
+		return Choice{}, false, err
+	}
+
+	return Choice{
+		Action:    action,
+		Cost:      action.Cost(),
+		Heuristic: next.Distance(goal),
+	}, true, nil
+}
+
+// Mismatch represents a rule of a goal which is not satisfied by a state.
+type Mismatch struct {
+	Rule   Rule    // The unsatisfied rule of the goal, such as "food>80"
+	Actual Rule    // The actual value of the fact in the state, zero if missing
+	Found  bool    // Whether the fact is present in the state
+	Gap    float32 // The distance between the actual value and the goal, if comparable
+}
+
+// String returns a human-readable description of the mismatch, such as "food=62, needed >80".
+func (m Mismatch) String() string {
+	needed := m.Rule.Expr().String()
+	if d, ok := domainOf(m.Rule.Fact()); ok {
+		needed = d.format(m.Rule.Expr())
+	}
+
+	if !m.Found {
+		return fmt.Sprintf("%s is missing, needed %s", m.Rule.Fact(), needed)
+	}
+	return fmt.Sprintf("%s, needed %s", m.Actual, needed)
+}
+
+// Explain returns exactly which rules of the goal are not satisfied by the state and by
+// how much, for example "food=62, needed >80". It is empty if the state matches the goal.
+func (state *State) Explain(goal *State) []Mismatch {
+	var out []Mismatch
+	for _, r := range goal.vx {
+		e, ok := state.lookup(r.Fact())
+		if !ok {
+			out = append(out, Mismatch{Rule: r, Gap: gap(r.Expr(), 0)})
+			continue
+		}
+
+		if match, err := satisfies(r.Fact(), r.Expr(), e); match && err == nil {
+			continue
+		}
+
+		m := Mismatch{Rule: r, Actual: ruleOf(r.Fact(), e), Found: true}
+		if e.Operator() == opEqual {
+			m.Gap = gap(r.Expr(), e.Value())
+		}
+		out = append(out, m)
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainPlan(t *testing.T) {
+	start, goal := StateOf("hunger=80", "!food", "!tired"), StateOf("food>80")
+	actions := []Action{
+		actionOf("Eat", 1.0, StateOf("food>0"), StateOf("hunger-50", "food-5")),
+		actionOf("Forage", 1.0, StateOf("tired<50"), StateOf("tired+20", "food+10", "hunger+5")),
+		actionOf("Sleep", 1.0, StateOf("tired>30"), StateOf("tired-50")),
+	}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+
+	steps, err := ExplainPlan(start, goal, plan, actions)
+	assert.NoError(t, err)
+	assert.Len(t, steps, len(plan))
+
+	// Only foraging is possible at first
+	assert.Equal(t, plan[0], steps[0].Chosen.Action)
+	assert.Empty(t, steps[0].Alternatives)
+	assert.Equal(t, float32(70), steps[0].Chosen.Heuristic)
+
+	// Then eating is possible, but it moves away from the goal
+	assert.Len(t, steps[1].Alternatives, 1)
+	assert.Equal(t, "Eat", nameOf(steps[1].Alternatives[0].Action))
+	assert.Greater(t, steps[1].Alternatives[0].Score(), steps[1].Chosen.Score())
+	assert.Equal(t, "2. Forage (cost=1, heuristic=60)\n   - Eat (cost +0, heuristic +15)\n", steps[1].String())
+}
+
+func TestExplainInvalidPlan(t *testing.T) {
+	_, err := ExplainPlan(StateOf("A"), StateOf("C"), []Action{move("B->C")}, []Action{move("A->B"), move("B->C")})
+	assert.ErrorIs(t, err, ErrPrecondition)
+}
+
+func TestStateExplain(t *testing.T) {
+	state := StateOf("food=62", "tired=10", "A")
+	assert.Empty(t, state.Explain(StateOf("food>50", "A")))
+
+	out := state.Explain(StateOf("food>80", "tired<20", "!A", "B"))
+	assert.Len(t, out, 3)
+
+	reasons := make([]string, 0, len(out))
+	for _, m := range out {
+		reasons = append(reasons, m.String())
+	}
+
+	assert.ElementsMatch(t, []string{
+		"food=62, needed >80",
+		"A=100, needed =0",
+		"B is missing, needed =100",
+	}, reasons)
+
+	for _, m := range out {
+		switch m.Rule.Fact().String() {
+		case "food":
+			assert.Equal(t, float32(18), m.Gap)
+		case "B":
+			assert.False(t, m.Found)
+			assert.Equal(t, float32(100), m.Gap)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/zeebo/xxh3"
+)
+
+// HashPlan returns a stable fingerprint of the plan, which only changes if the names,
+// costs, requirements or outcomes of its actions, or their order, change. It can be used
+// as a cache key or to check that a replay produces the same plan.
+func HashPlan(plan []Action) uint64 {
+	buffer := make([]byte, 0, 64*len(plan))
+	for _, action := range plan {
+		buffer = binary.LittleEndian.AppendUint64(buffer, hashAction(action))
+	}
+	return xxh3.Hash(buffer)
+}
+
+// HashDomain returns a stable fingerprint of the set of actions, regardless of their
+// order, which only changes if an action is added, removed or modified. Actions which
+// do not describe themselves are simulated against an empty state, hence for actions
+// that depend on the current state the fingerprint only covers their static part.
+func HashDomain(actions []Action) uint64 {
+	hashes := make([]uint64, 0, len(actions))
+	for _, action := range actions {
+		hashes = append(hashes, hashAction(action))
+	}
+
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	buffer := make([]byte, 0, 8*len(hashes))
+	for _, h := range hashes {
+		buffer = binary.LittleEndian.AppendUint64(buffer, h)
+	}
+	return xxh3.Hash(buffer)
+}
+
+// hashAction returns the fingerprint of a single action, based on its description.
+func hashAction(action Action) uint64 {
+	desc := DescribeAction(action)
+	buffer := make([]byte, 0, 64)
+	buffer = appendString(buffer, desc.Name)
+	buffer = binary.LittleEndian.AppendUint32(buffer, math.Float32bits(desc.Cost))
+	buffer = appendRules(buffer, desc.Require)
+	buffer = appendRules(buffer, desc.Outcome)
+	for _, tag := range desc.Tags {
+		buffer = appendString(buffer, tag)
+	}
+	return xxh3.Hash(buffer)
+}
+
+// appendString appends the length-prefixed string to the buffer.
+func appendString(buffer []byte, s string) []byte {
+	buffer = binary.LittleEndian.AppendUint32(buffer, uint32(len(s)))
+	return append(buffer, s...)
+}
+
+// appendRules appends the length-prefixed rules to the buffer. Referenced facts and the
+// bounds of the ranges are written instead of their index in the registries, which
+// depends on the order in which the rules were first parsed.
+func appendRules(buffer []byte, rules []Rule) []byte {
+	buffer = binary.LittleEndian.AppendUint32(buffer, uint32(len(rules)))
+	for _, r := range rules {
+		buffer = binary.LittleEndian.AppendUint32(buffer, uint32(r.Fact()))
+		if ref, ok := r.Expr().Ref(); ok {
+			buffer = binary.LittleEndian.AppendUint32(buffer, uint32(r.Expr().Operator())<<28)
+			buffer = binary.LittleEndian.AppendUint32(buffer, uint32(ref))
+			continue
+		}
+
+		if lo, hi, ok := r.Expr().Band(); ok {
+			buffer = binary.LittleEndian.AppendUint32(buffer, uint32(r.Expr().Operator())<<28)
+			buffer = binary.LittleEndian.AppendUint32(buffer, uint32(lo))
+			buffer = binary.LittleEndian.AppendUint32(buffer, uint32(hi))
+			continue
+		}
+
+		buffer = binary.LittleEndian.AppendUint32(buffer, uint32(r.Expr()))
+	}
+	return buffer
+}
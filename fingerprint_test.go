@@ -0,0 +1,34 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashDomain(t *testing.T) {
+	a, b := move("A->B"), move("B->C")
+	assert.Equal(t, HashDomain([]Action{a, b}), HashDomain([]Action{b, a}))
+	assert.Equal(t, HashDomain([]Action{a, b}), HashDomain([]Action{move("B->C"), move("A->B")}))
+	assert.NotEqual(t, HashDomain([]Action{a, b}), HashDomain([]Action{a, move("B->C", 2)}))
+	assert.NotEqual(t, HashDomain([]Action{a, b}), HashDomain([]Action{a}))
+	assert.NotEqual(t, HashDomain([]Action{a}), HashDomain([]Action{actionOf("A->B", 1, StateOf("A"), StateOf("B"))}))
+
+	// Plans are sensitive to the order of the actions
+	assert.Equal(t, HashPlan([]Action{a, b}), HashPlan([]Action{move("A->B"), move("B->C")}))
+	assert.NotEqual(t, HashPlan([]Action{a, b}), HashPlan([]Action{b, a}))
+	assert.NotEqual(t, HashPlan(nil), HashPlan([]Action{a}))
+
+	// References are hashed by the referenced fact
+	x := actionOf("Eat", 1, StateOf(), StateOf("food+(forage_skill)"))
+	y := actionOf("Eat", 1, StateOf(), StateOf("food+(hunt_skill)"))
+	assert.NotEqual(t, HashPlan([]Action{x}), HashPlan([]Action{y}))
+
+	// Ranges are hashed by their bounds, rather than their index in the registry
+	spread := actionOf("Forage", 1, StateOf("food>10<30"), StateOf("food+5..15"))
+	assert.Equal(t, HashPlan([]Action{spread}), HashPlan([]Action{actionOf("Forage", 1, StateOf("food>10<30"), StateOf("food+5..15"))}))
+	assert.NotEqual(t, HashPlan([]Action{spread}), HashPlan([]Action{actionOf("Forage", 1, StateOf("food>10<30"), StateOf("food+5..16"))}))
+}
@@ -2,7 +2,10 @@ module github.com/kelindar/goap
 
 go 1.21
 
-require github.com/zeebo/xxh3 v1.0.2
+require (
+	github.com/kelindar/event v1.5.2
+	github.com/zeebo/xxh3 v1.0.2
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -0,0 +1,171 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"errors"
+	"sort"
+)
+
+// GoalFunc represents a predicate goal, for goals which can't be expressed as a simple
+// conjunction of rules, such as "any three resources above 50". It returns whether the
+// goal is achieved in the state, along with an estimate of the distance to the goal.
+type GoalFunc func(state *State) (achieved bool, distance float32)
+
+// objective represents the goal of a search, either a desired state or a predicate.
+type objective struct {
+	state *State                     // The desired state, if any
+	fn    GoalFunc                   // The predicate, if any
+	match func(*State) (bool, error) // The predicate of the expanded states without any heuristic, if any
+}
+
+// goalOf creates an objective from a desired state.
+func goalOf(state *State) objective {
+	return objective{state: state}
+}
+
+// Match checks whether the goal is achieved in the state.
+func (g objective) Match(state *State) (bool, error) {
+	if g.match != nil {
+		return g.match(state)
+	}
+
+	if g.fn != nil {
+		achieved, _ := g.fn(state)
+		return achieved, nil
+	}
+
+	return state.Match(g.state)
+}
+
+// Distance estimates the distance from the state to the goal.
+func (g objective) Distance(state *State) float32 {
+	if g.match != nil {
+		return 0
+	}
+
+	if g.fn != nil {
+		_, distance := g.fn(state)
+		return distance
+	}
+
+	return state.Distance(g.state)
+}
+
+// ------------------------------------ Goal Selection ------------------------------------
+
+// Goal represents a goal an agent may pursue, whose priority may depend on the current
+// state of the world, such as eating becoming more important as hunger grows.
+type Goal interface {
+
+	// Desired returns the desired state of the world.
+	Desired() *State
+
+	// Priority returns the priority of the goal in the current state.
+	Priority(current *State) float32
+
+	// Achieved returns whether the goal is achieved in the current state.
+	Achieved(current *State) bool
+}
+
+// StateGoal represents a goal of reaching a desired state, achieved once the current state
+// matches it, with an optional priority function.
+type StateGoal struct {
+	Name   string                       // The name of the goal
+	State  *State                       // The desired state
+	Weight func(current *State) float32 // The priority in the current state, 1 if nil
+}
+
+// Desired returns the desired state of the world.
+func (g *StateGoal) Desired() *State {
+	return g.State
+}
+
+// Priority returns the priority of the goal in the current state.
+func (g *StateGoal) Priority(current *State) float32 {
+	if g.Weight == nil {
+		return 1
+	}
+	return g.Weight(current)
+}
+
+// Achieved returns whether the current state matches the desired state.
+func (g *StateGoal) Achieved(current *State) bool {
+	ok, err := current.Match(g.State)
+	return ok && err == nil
+}
+
+// String returns the name of the goal.
+func (g *StateGoal) String() string {
+	return g.Name
+}
+
+// GoalSelector selects which goal an agent should pursue among a set of goals, the one
+// with the highest priority among the ones not yet achieved.
+type GoalSelector struct {
+	goals []Goal
+}
+
+// NewGoalSelector creates a new selector among the goals.
+func NewGoalSelector(goals ...Goal) *GoalSelector {
+	return &GoalSelector{goals: goals}
+}
+
+// Select returns the goal with the highest priority which is not achieved in the current
+// state, or false if every goal is already achieved.
+func (s *GoalSelector) Select(current *State) (Goal, bool) {
+	ranked := s.rank(current)
+	if len(ranked) == 0 {
+		return nil, false
+	}
+	return ranked[0], true
+}
+
+// rank returns the goals not achieved in the current state, by decreasing priority.
+func (s *GoalSelector) rank(current *State) []Goal {
+	type entry struct {
+		goal     Goal
+		priority float32
+	}
+
+	entries := make([]entry, 0, len(s.goals))
+	for _, g := range s.goals {
+		if !g.Achieved(current) {
+			entries = append(entries, entry{goal: g, priority: g.Priority(current)})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].priority > entries[j].priority
+	})
+
+	ranked := make([]Goal, 0, len(entries))
+	for _, e := range entries {
+		ranked = append(ranked, e.goal)
+	}
+	return ranked
+}
+
+// PlanGoal selects the goal with the highest priority which is not achieved in the current
+// state and can be planned for, and returns it along with its plan. The goals which cannot
+// be reached are skipped in favor of the next ones, and the error of the most important
+// one is returned if none can be reached.
+func (p *Planner) PlanGoal(current *State, selector *GoalSelector, opts ...Option) (Goal, []Action, error) {
+	var first error
+	for _, goal := range selector.rank(current) {
+		plan, err := p.Plan(current, goal.Desired(), opts...)
+		if err == nil {
+			return goal, plan, nil
+		}
+
+		if first == nil {
+			first = err
+		}
+	}
+
+	if first == nil {
+		first = errors.New("plan: every goal is already achieved")
+	}
+	return nil, nil, first
+}
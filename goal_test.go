@@ -0,0 +1,58 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoalSelector(t *testing.T) {
+	hunger, _ := CompileRule("hunger")
+	eat := &StateGoal{Name: "eat", State: StateOf("hunger<20"), Weight: func(current *State) float32 {
+		e, _ := current.lookup(hunger.Fact())
+		return e.Value() / 10
+	}}
+	rest := &StateGoal{Name: "rest", State: StateOf("tired<20"), Weight: func(*State) float32 { return 5 }}
+	idle := &StateGoal{Name: "idle", State: StateOf("B")}
+	selector := NewGoalSelector(eat, rest, idle)
+
+	// The priority of eating grows with hunger
+	goal, ok := selector.Select(StateOf("hunger=80", "tired=50"))
+	assert.True(t, ok)
+	assert.Equal(t, eat, goal)
+
+	goal, ok = selector.Select(StateOf("hunger=30", "tired=50"))
+	assert.True(t, ok)
+	assert.Equal(t, rest, goal)
+
+	// Achieved goals are skipped
+	goal, ok = selector.Select(StateOf("hunger=10", "tired=10"))
+	assert.True(t, ok)
+	assert.Equal(t, idle, goal)
+
+	_, ok = selector.Select(StateOf("hunger=10", "tired=10", "B"))
+	assert.False(t, ok)
+}
+
+func TestPlanGoal(t *testing.T) {
+	planner, err := NewPlanner([]Action{move("A->B")})
+	assert.NoError(t, err)
+
+	// The most important goal cannot be reached, so the next one is pursued
+	unreachable := &StateGoal{Name: "fly", State: StateOf("Z"), Weight: func(*State) float32 { return 10 }}
+	walk := &StateGoal{Name: "walk", State: StateOf("B")}
+	goal, plan, err := planner.PlanGoal(StateOf("A"), NewGoalSelector(unreachable, walk))
+	assert.NoError(t, err)
+	assert.Equal(t, walk, goal)
+	assert.Equal(t, []string{"A->B"}, planOf(plan))
+	assert.Equal(t, "walk", walk.String())
+
+	_, _, err = planner.PlanGoal(StateOf("A"), NewGoalSelector(unreachable))
+	assert.ErrorContains(t, err, "facts Z cannot be influenced")
+
+	_, _, err = planner.PlanGoal(StateOf("B"), NewGoalSelector(walk))
+	assert.ErrorContains(t, err, "already achieved")
+}
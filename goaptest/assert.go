@@ -0,0 +1,100 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goaptest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kelindar/goap"
+)
+
+// AssertPlanValid asserts that the plan is valid, by re-applying the outcomes of every
+// action from the start state, checking their requirements along the way, and finally
+// checking that the goal is reached. It returns whether the assertion succeeded.
+func AssertPlanValid(t testing.TB, start, goal *goap.State, plan []goap.Action) bool {
+	t.Helper()
+	if err := goap.VerifyPlan(start, goal, plan); err != nil {
+		t.Errorf("invalid plan %s from %s: %v", strings.Join(names(plan), ", "), start, err)
+		return false
+	}
+	return true
+}
+
+// AssertPlanEquals asserts that the plan consists of the actions with the expected names,
+// in order, and reports the differences step by step otherwise. It returns whether the
+// assertion succeeded.
+func AssertPlanEquals(t testing.TB, expect []string, plan []goap.Action) bool {
+	t.Helper()
+	actual := names(plan)
+	if equal(expect, actual) {
+		return true
+	}
+
+	t.Errorf("plans are not equal (-expected +actual):\n%s", diff(expect, actual))
+	return false
+}
+
+// names returns the names of the actions of the plan.
+func names(plan []goap.Action) []string {
+	out := make([]string, 0, len(plan))
+	for _, action := range plan {
+		if s, ok := action.(fmt.Stringer); ok {
+			out = append(out, s.String())
+			continue
+		}
+		out = append(out, fmt.Sprintf("%T", action))
+	}
+	return out
+}
+
+// equal returns whether the two lists of names are equal.
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// diff returns the line diff of the two plans, based on their longest common subsequence.
+func diff(expect, actual []string) string {
+	lcs := make([][]int, len(expect)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(actual)+1)
+	}
+
+	for i := len(expect) - 1; i >= 0; i-- {
+		for j := len(actual) - 1; j >= 0; j-- {
+			if expect[i] == actual[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(expect) || j < len(actual) {
+		switch {
+		case i < len(expect) && j < len(actual) && expect[i] == actual[j]:
+			fmt.Fprintf(&sb, "  %d. %s\n", j+1, actual[j])
+			i, j = i+1, j+1
+		case j < len(actual) && (i == len(expect) || lcs[i][j+1] >= lcs[i+1][j]):
+			fmt.Fprintf(&sb, "+ %d. %s\n", j+1, actual[j])
+			j++
+		default:
+			fmt.Fprintf(&sb, "- %d. %s\n", i+1, expect[i])
+			i++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,46 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goaptest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kelindar/goap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertPlan(t *testing.T) {
+	ab := &Action{Name: "A->B", Weight: 1, Require: goap.StateOf("A"), Outcome: goap.StateOf("!A", "B")}
+	bc := &Action{Name: "B->C", Weight: 1, Require: goap.StateOf("B"), Outcome: goap.StateOf("!B", "C")}
+	start, goal := goap.StateOf("A"), goap.StateOf("C")
+
+	plan, err := goap.Plan(start, goal, []goap.Action{ab, bc})
+	assert.NoError(t, err)
+	assert.True(t, AssertPlanValid(t, start, goal, plan))
+	assert.True(t, AssertPlanEquals(t, []string{"A->B", "B->C"}, plan))
+
+	// Failures are reported to the test
+	mock := new(mockT)
+	assert.False(t, AssertPlanValid(mock, start, goal, []goap.Action{bc}))
+	assert.Contains(t, mock.errors[0], "invalid plan B->C")
+
+	assert.False(t, AssertPlanEquals(mock, []string{"A->B", "B->C"}, []goap.Action{ab, ab, bc}))
+	assert.Contains(t, mock.errors[1], "  1. A->B\n+ 2. A->B\n  3. B->C\n")
+
+	assert.False(t, AssertPlanEquals(mock, []string{"A->B", "B->C"}, []goap.Action{bc}))
+	assert.Contains(t, mock.errors[2], "- 1. A->B\n  1. B->C\n")
+}
+
+// mockT records the errors reported by the assertions.
+type mockT struct {
+	testing.TB
+	errors []string
+}
+
+func (m *mockT) Helper() {}
+
+func (m *mockT) Errorf(format string, args ...any) {
+	m.errors = append(m.errors, fmt.Sprintf(format, args...))
+}
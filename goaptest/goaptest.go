@@ -0,0 +1,170 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+// Package goaptest provides generators of random states, rules and actions along
+// with invariants, which can be used to property-test planning domains, as well as
+// assertions to regression-test the plans of a domain against golden plans.
+package goaptest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/kelindar/goap"
+)
+
+// Generator generates random, but valid, rules, states and actions over a fixed
+// set of facts. It is deterministic for a given seed.
+type Generator struct {
+	rand  *rand.Rand
+	facts []string
+}
+
+// New creates a new generator over the specified number of facts.
+func New(seed int64, facts int) *Generator {
+	names := make([]string, max(facts, 1))
+	for i := range names {
+		names[i] = "f" + strconv.Itoa(i)
+	}
+
+	return &Generator{
+		rand:  rand.New(rand.NewSource(seed)),
+		facts: names,
+	}
+}
+
+// Fact returns a random fact name.
+func (g *Generator) Fact() string {
+	return g.facts[g.rand.Intn(len(g.facts))]
+}
+
+// Value returns a random value, as a multiple of 10 between 0 and 100.
+func (g *Generator) Value() int {
+	return g.rand.Intn(11) * 10
+}
+
+// Require returns a random requirement rule, using one of '=', '<' or '>'.
+func (g *Generator) Require() string {
+	switch g.rand.Intn(3) {
+	case 0:
+		return g.Fact() + "=" + strconv.Itoa(g.Value())
+	case 1:
+		return g.Fact() + "<" + strconv.Itoa(max(g.Value(), 10))
+	default:
+		return g.Fact() + ">" + strconv.Itoa(min(g.Value(), 90))
+	}
+}
+
+// Effect returns a random effect rule, using one of '=', '+' or '-'.
+func (g *Generator) Effect() string {
+	switch g.rand.Intn(3) {
+	case 0:
+		return g.Fact() + "=" + strconv.Itoa(g.Value())
+	case 1:
+		return g.Fact() + "+" + strconv.Itoa(max(g.Value(), 10))
+	default:
+		return g.Fact() + "-" + strconv.Itoa(max(g.Value(), 10))
+	}
+}
+
+// State returns a random state with up to the specified number of facts.
+func (g *Generator) State(size int) *goap.State {
+	rules := make([]string, 0, size)
+	for i := 0; i < size; i++ {
+		rules = append(rules, g.Fact()+"="+strconv.Itoa(g.Value()))
+	}
+	return goap.StateOf(rules...)
+}
+
+// Goal returns a random goal with up to the specified number of requirements.
+func (g *Generator) Goal(size int) *goap.State {
+	return goap.StateOf(g.rules(size, g.Require)...)
+}
+
+// Actions returns the specified number of random actions, each with up to two
+// requirements and up to three effects.
+func (g *Generator) Actions(count int) []goap.Action {
+	actions := make([]goap.Action, 0, count)
+	for i := 0; i < count; i++ {
+		actions = append(actions, &Action{
+			Name:    "a" + strconv.Itoa(i),
+			Weight:  float32(1 + g.rand.Intn(5)),
+			Require: goap.StateOf(g.rules(g.rand.Intn(3), g.Require)...),
+			Outcome: goap.StateOf(g.rules(1+g.rand.Intn(3), g.Effect)...),
+		})
+	}
+	return actions
+}
+
+// rules generates a list of rules with distinct facts
+func (g *Generator) rules(size int, next func() string) []string {
+	seen := make(map[string]bool, size)
+	rules := make([]string, 0, size)
+	for i := 0; i < size; i++ {
+		rule := next()
+		fact := rule[:strings.IndexAny(rule, "=<>+-")]
+		if !seen[fact] {
+			seen[fact] = true
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// ------------------------------------ Action ------------------------------------
+
+// Action represents a static action with fixed requirements and outcomes.
+type Action struct {
+	Name    string
+	Weight  float32
+	Require *goap.State
+	Outcome *goap.State
+}
+
+// Simulate returns the requirements and outcomes of the action.
+func (a *Action) Simulate(_ *goap.State) (*goap.State, *goap.State) {
+	return a.Require, a.Outcome
+}
+
+// Cost returns the cost of the action.
+func (a *Action) Cost() float32 {
+	return a.Weight
+}
+
+// String returns the name of the action.
+func (a *Action) String() string {
+	return a.Name
+}
+
+// ------------------------------------ Invariants ------------------------------------
+
+// CheckPlan plans from the start to the goal and checks the invariants of the
+// resulting plan. Failing to find a plan is not a violation, but any other error
+// is, and any returned plan must be valid and must reach the goal.
+func CheckPlan(start, goal *goap.State, actions []goap.Action) error {
+	plan, err := goap.Plan(start, goal, actions)
+	switch {
+	case errors.Is(err, goap.ErrNoPlan):
+		return nil
+	case err != nil:
+		return fmt.Errorf("planning from %s: %w", start, err)
+	}
+
+	if err := goap.VerifyPlan(start, goal, plan); err != nil {
+		return fmt.Errorf("invalid plan %v from %s: %w", plan, start, err)
+	}
+	return nil
+}
+
+// Describe returns the structured description of the action.
+func (a *Action) Describe() goap.Description {
+	return goap.Description{
+		Name:    a.Name,
+		Cost:    a.Weight,
+		Require: a.Require.Rules(),
+		Outcome: a.Outcome.Rules(),
+	}
+}
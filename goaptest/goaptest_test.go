@@ -0,0 +1,39 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goaptest
+
+import (
+	"testing"
+
+	"github.com/kelindar/goap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerator(t *testing.T) {
+	g1, g2 := New(42, 5), New(42, 5)
+	assert.Equal(t, g1.State(4).String(), g2.State(4).String())
+	assert.Equal(t, g1.Goal(2).String(), g2.Goal(2).String())
+	assert.Len(t, g1.Actions(10), 10)
+	assert.Equal(t, 4, g1.Goal(4).Len())
+
+	for i := 0; i < 100; i++ {
+		_, err := goap.CompileRule(g1.Require())
+		assert.NoError(t, err)
+		_, err = goap.CompileRule(g1.Effect())
+		assert.NoError(t, err)
+	}
+}
+
+func TestProperties(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		g := New(seed, 4)
+		start, goal := g.State(4), g.Goal(2)
+		actions := g.Actions(6)
+		assert.NoError(t, CheckPlan(start, goal, actions), "seed=%d", seed)
+	}
+
+	// Errors other than not finding a plan are violations
+	err := CheckPlan(goap.StateOf("A"), goap.StateOf("B"), make([]goap.Action, goap.MaxActions+1))
+	assert.ErrorIs(t, err, goap.ErrTooManyActions)
+}
@@ -0,0 +1,103 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "sort"
+
+// Heat represents how often a single fact was read and written during the searches.
+type Heat struct {
+	Fact   string // The name of the fact
+	Reads  uint64 // Number of times the fact was read by a requirement or the goal
+	Writes uint64 // Number of times the fact was written by an outcome
+}
+
+// Heatmap represents how often every fact is read by the requirements and the goal and
+// written by the outcomes of the actions during the searches, showing which facts dominate
+// the branching and which are dead weight in a domain. A heatmap accumulates the tallies
+// of every search it is passed to with WithHeatmap and is not safe for concurrent use.
+type Heatmap struct {
+	reads  map[fact]uint64
+	writes map[fact]uint64
+}
+
+// Reads returns the number of times the fact was read.
+func (h *Heatmap) Reads(name string) uint64 {
+	return h.reads[factOf(name)]
+}
+
+// Writes returns the number of times the fact was written.
+func (h *Heatmap) Writes(name string) uint64 {
+	return h.writes[factOf(name)]
+}
+
+// Facts returns the tallies of every fact, from the most to the least used one.
+func (h *Heatmap) Facts() []Heat {
+	tally := make(map[fact]*Heat, len(h.reads))
+	heat := func(f fact) *Heat {
+		if v, ok := tally[f]; ok {
+			return v
+		}
+
+		v := &Heat{Fact: f.String()}
+		tally[f] = v
+		return v
+	}
+
+	for f, n := range h.reads {
+		heat(f).Reads = n
+	}
+	for f, n := range h.writes {
+		heat(f).Writes = n
+	}
+
+	out := make([]Heat, 0, len(tally))
+	for _, v := range tally {
+		out = append(out, *v)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].Reads+out[i].Writes, out[j].Reads+out[j].Writes
+		if a != b {
+			return a > b
+		}
+		return out[i].Fact < out[j].Fact
+	})
+	return out
+}
+
+// Reset clears all of the tallies.
+func (h *Heatmap) Reset() {
+	clear(h.reads)
+	clear(h.writes)
+}
+
+// read tallies a read of every fact of the state.
+func (h *Heatmap) read(state *State) {
+	if h == nil || state == nil {
+		return
+	}
+
+	if h.reads == nil {
+		h.reads = make(map[fact]uint64, 16)
+	}
+
+	for _, r := range state.vx {
+		h.reads[r.Fact()]++
+	}
+}
+
+// write tallies a write of every fact of the state.
+func (h *Heatmap) write(state *State) {
+	if h == nil {
+		return
+	}
+
+	if h.writes == nil {
+		h.writes = make(map[fact]uint64, 16)
+	}
+
+	for _, r := range state.vx {
+		h.writes[r.Fact()]++
+	}
+}
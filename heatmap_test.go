@@ -0,0 +1,34 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeatmap(t *testing.T) {
+	var heatmap Heatmap
+	_, err := Plan(StateOf("A", "noise"), StateOf("C"), []Action{
+		move("A->B"),
+		move("B->C"),
+		actionOf("Shout", 1, StateOf(), StateOf("noise+10")),
+	}, WithHeatmap(&heatmap))
+	assert.NoError(t, err)
+
+	assert.Equal(t, uint64(3), heatmap.Reads("C"))
+	assert.NotZero(t, heatmap.Reads("A"))
+	assert.Zero(t, heatmap.Reads("noise"))
+	assert.NotZero(t, heatmap.Writes("noise"))
+
+	facts := heatmap.Facts()
+	assert.Len(t, facts, 4)
+	for _, f := range facts {
+		assert.NotZero(t, f.Reads+f.Writes, f.Fact)
+	}
+
+	heatmap.Reset()
+	assert.Empty(t, heatmap.Facts())
+}
@@ -0,0 +1,67 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// Change represents a single change of a state, recorded in its history.
+type Change struct {
+	Action  Action // The action responsible for the change, if any
+	Effects []Rule // The effects which were applied
+	Result  []Rule // The resulting values of the facts changed by the effects
+}
+
+// history represents a bounded history of the changes of a state, kept in a ring buffer.
+type history struct {
+	changes []Change // Ring buffer of the changes
+	next    int      // Next position in the ring buffer to write
+	full    bool     // Whether the ring buffer has wrapped around
+}
+
+// EnableHistory records the last changes applied to the state with Apply or ApplyAction,
+// along with the responsible action, which helps debugging questions such as "how did
+// hunger get to 0?". The history is disabled by default, has no overhead when disabled
+// and is not carried over to the clones of the state. A size of 0 disables it.
+func (s *State) EnableHistory(size int) {
+	if size <= 0 {
+		s.log = nil
+		return
+	}
+
+	s.log = &history{
+		changes: make([]Change, size),
+	}
+}
+
+// History returns the recorded changes of the state, from the oldest to the most recent.
+func (s *State) History() []Change {
+	if s.log == nil {
+		return nil
+	}
+
+	if !s.log.full {
+		return append([]Change(nil), s.log.changes[:s.log.next]...)
+	}
+
+	out := make([]Change, 0, len(s.log.changes))
+	out = append(out, s.log.changes[s.log.next:]...)
+	return append(out, s.log.changes[:s.log.next]...)
+}
+
+// record records the change of the state due to the effects of the action.
+func (h *history) record(state *State, action Action, effects *State) {
+	change := Change{
+		Action:  action,
+		Effects: append([]Rule(nil), effects.vx...),
+		Result:  make([]Rule, 0, len(effects.vx)),
+	}
+
+	for _, r := range effects.vx {
+		change.Result = append(change.Result, ruleOf(r.Fact(), state.load(r.Fact())))
+	}
+
+	h.changes[h.next] = change
+	h.next++
+	if h.next == len(h.changes) {
+		h.next, h.full = 0, true
+	}
+}
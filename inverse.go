@@ -0,0 +1,68 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "fmt"
+
+// InverseOf generates the inverse of an action with simple '=' outcomes, undoing its
+// outcomes at the specified cost, which is useful for reversible domains such as
+// movement and toggles. The inverse requires the outcomes of the action and restores the
+// values of the facts the action requires, or negates them for the boolean facts (either
+// 0 or 100) which the action does not require. The action is simulated against an empty
+// state, hence it must not depend on the current state.
+func InverseOf(action Action, cost float32) (Action, error) {
+	require, outcome := describe(action, nil)
+	defer require.release()
+	defer outcome.release()
+
+	name := nameOf(action)
+
+	inverse := &definedAction{
+		name:    "undo " + name,
+		cost:    cost,
+		require: newState(outcome.Len()),
+		outcome: newState(outcome.Len()),
+	}
+
+	for _, r := range outcome.vx {
+		f, e := r.Fact(), r.Expr()
+		if e.Operator() != opEqual {
+			return nil, &ActionError{Action: name, Err: factError(r, fmt.Errorf("plan: cannot inverse action '%s', effect '%s' is not an assignment", name, r))}
+		}
+
+		// Restore the value the action requires or, for boolean facts, negate it
+		var prior expr
+		switch i, ok := require.find(f); {
+		case ok && require.vx[i].Expr().Operator() == opEqual:
+			prior = require.vx[i].Expr()
+		case e.Fixed() == MinValue*scale:
+			prior = exprOf(opEqual, MaxValue)
+		case e.Fixed() == MaxValue*scale:
+			prior = exprOf(opEqual, MinValue)
+		default:
+			return nil, &ActionError{Action: name, Err: factError(r, fmt.Errorf("plan: cannot inverse action '%s', prior value of '%s' is unknown", name, f))}
+		}
+
+		inverse.require.store(f, e)
+		inverse.outcome.store(f, prior)
+	}
+
+	return inverse, nil
+}
+
+// WithInverses returns the actions along with their inverses, generated with InverseOf at
+// the specified cost.
+func WithInverses(actions []Action, cost float32) ([]Action, error) {
+	out := make([]Action, 0, 2*len(actions))
+	out = append(out, actions...)
+	for _, action := range actions {
+		inverse, err := InverseOf(action, cost)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, inverse)
+	}
+	return out, nil
+}
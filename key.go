@@ -0,0 +1,77 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// Key represents a typed fact key, declared once with a user-defined string type such as
+// "type Need string", so that the facts of a domain are Go identifiers rather than strings
+// and typos such as "hungr=80" are caught at compile time. The rules built from a key are
+// the same packed rules as the parsed ones, without any parsing. Values outside of the
+// range of the fact saturate to its bounds.
+type Key[K ~string] struct {
+	name K    // The name of the fact
+	fact fact // The precomputed fact
+}
+
+// KeyOf creates a new typed fact key, for example "var Hunger = goap.KeyOf(Need("hunger"))".
+// It panics if the name is not a valid fact name.
+func KeyOf[K ~string](name K) Key[K] {
+	rule, err := CompileRule(string(name))
+	switch {
+	case err != nil:
+		panic(err)
+	case rule.Expr() != exprOf(opEqual, MaxValue):
+		panic(&ParseError{Rule: string(name), Reason: "invalid fact name"})
+	}
+
+	return Key[K]{name: name, fact: rule.Fact()}
+}
+
+// Name returns the name of the fact.
+func (k Key[K]) Name() K {
+	return k.name
+}
+
+// Is returns the rule setting or requiring the fact to be equal to the value.
+func (k Key[K]) Is(value float32) Rule {
+	return k.rule(opEqual, value)
+}
+
+// Less returns the rule requiring the fact to be less than the value.
+func (k Key[K]) Less(value float32) Rule {
+	return k.rule(opLess, value)
+}
+
+// Greater returns the rule requiring the fact to be greater than the value.
+func (k Key[K]) Greater(value float32) Rule {
+	return k.rule(opGreater, value)
+}
+
+// Inc returns the rule incrementing the fact by the delta.
+func (k Key[K]) Inc(delta float32) Rule {
+	return k.rule(opIncrement, delta)
+}
+
+// Dec returns the rule decrementing the fact by the delta.
+func (k Key[K]) Dec(delta float32) Rule {
+	return k.rule(opDecrement, delta)
+}
+
+// True returns the rule setting or requiring the boolean fact, such as "food".
+func (k Key[K]) True() Rule {
+	return ruleOf(k.fact, exprOf(opEqual, MaxValue))
+}
+
+// False returns the rule unsetting or requiring the absence of the fact, such as "!food".
+func (k Key[K]) False() Rule {
+	return ruleOf(k.fact, exprOf(opEqual, MinValue))
+}
+
+// rule returns the rule of the fact with the operator and the value, expressed in the
+// unit of the fact if declared.
+func (k Key[K]) rule(op operator, value float32) Rule {
+	if d, ok := domainOf(k.fact); ok {
+		value = d.normalize(op, value)
+	}
+	return ruleOf(k.fact, exprOf(op, value))
+}
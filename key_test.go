@@ -0,0 +1,43 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type need string
+
+func TestKey(t *testing.T) {
+	hunger, food, tired := KeyOf(need("hunger")), KeyOf(need("food")), KeyOf(need("tired"))
+	assert.Equal(t, need("hunger"), hunger.Name())
+
+	start := StateOfRules(hunger.Is(80), food.False(), tired.False())
+	assert.Equal(t, StateOf("hunger=80", "!food", "!tired").Hash(), start.Hash())
+
+	actions := []Action{
+		actionOf("Eat", 1.0, StateOfRules(food.Greater(0)), StateOfRules(hunger.Dec(50), food.Dec(5))),
+		actionOf("Forage", 1.0, StateOfRules(tired.Less(50)), StateOfRules(tired.Inc(20), food.Inc(10), hunger.Inc(5))),
+		actionOf("Sleep", 1.0, StateOfRules(tired.Greater(30)), StateOfRules(tired.Dec(50))),
+	}
+
+	plan, err := Plan(start, StateOfRules(food.Greater(80)), actions)
+	assert.NoError(t, err)
+	assert.Len(t, plan, 12)
+	assert.Equal(t, "food=100", food.True().String())
+}
+
+func TestKeyUnit(t *testing.T) {
+	assert.NoError(t, DeclareFact("key_distance", UnitMeters, 0, 1000))
+	distance := KeyOf("key_distance")
+	assert.Equal(t, "key_distance<250m", distance.Less(250).String())
+	assert.Equal(t, "key_distance+50m", distance.Inc(50).String())
+}
+
+func TestKeyInvalid(t *testing.T) {
+	assert.Panics(t, func() { KeyOf("hunger=80") })
+	assert.Panics(t, func() { KeyOf("") })
+}
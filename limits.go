@@ -0,0 +1,106 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"errors"
+	"strings"
+)
+
+// Limits of the planner. Inputs exceeding these limits are rejected with one of
+// the typed errors below rather than being silently truncated.
+const (
+	MinValue   = 0       // Minimum value of a fact
+	MaxValue   = 100     // Maximum value of a fact
+	MaxFacts   = 1024    // Maximum number of facts in a single state
+	MaxActions = 1 << 16 // Maximum number of actions in a single domain
+	MaxCosts   = 4       // Maximum number of costs of a multi-cost action
+)
+
+var (
+	ErrValueRange     = errors.New("plan: value is out of range")
+	ErrTooManyFacts   = errors.New("plan: too many facts in a state")
+	ErrTooManyActions = errors.New("plan: too many actions in a domain")
+	ErrOverflow       = errors.New("plan: value overflow")
+	ErrBudget         = errors.New("plan: cost budget exceeded")
+	ErrTooManyCosts   = errors.New("plan: too many costs for an action")
+	ErrPrecondition   = errors.New("plan: precondition is not met")
+	ErrTooManyRanges  = errors.New("plan: too many distinct ranges")
+	ErrNoPlan         = errors.New("plan: no plan could be found to reach the goal")
+	ErrPrecision      = errors.New("plan: precision must be set before any rule is parsed")
+	ErrCaseSensitive  = errors.New("plan: case sensitivity must be set before any rule is parsed")
+)
+
+// FactError wraps an error caused by a rule, so the failing fact and expression can be
+// retrieved with errors.As rather than parsed from the message.
+type FactError struct {
+	Fact string // The name of the fact of the rule
+	Expr string // The expression of the rule, such as ">50", if any
+	Err  error  // The underlying error
+}
+
+// factError wraps the error with the fact and the expression of the rule.
+func factError(r Rule, err error) error {
+	name := r.Fact().String()
+	return &FactError{
+		Fact: name,
+		Expr: strings.TrimPrefix(r.String(), name),
+		Err:  err,
+	}
+}
+
+// Error returns the error message.
+func (e *FactError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FactError) Unwrap() error {
+	return e.Err
+}
+
+// ActionError wraps an error caused by an action, so the failing action can be retrieved
+// with errors.As rather than parsed from the message. The failures of a step of a plan are
+// reported with a *StepError instead, which also carries the index of the step.
+type ActionError struct {
+	Action string // The name of the action
+	Err    error  // The underlying error
+}
+
+// Error returns the error message.
+func (e *ActionError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// Overflow represents the behavior when an effect pushes a fact outside of its range.
+type Overflow uint8
+
+const (
+	OverflowSaturate Overflow = iota // Values saturate to the bounds of the range (default)
+	OverflowWrap                     // Values wrap around the range, like an angle
+	OverflowError                    // Values outside of the range cause an ErrOverflow
+)
+
+// Stance represents the outcome the planner assumes for the uncertain effects, such as
+// "food+5..15", when predicting the state of the world after an action.
+type Stance uint8
+
+const (
+	StanceExpected    Stance = iota // Assumes the middle of the range (default)
+	StancePessimistic               // Assumes the smallest increase or the largest decrease
+	StanceOptimistic                // Assumes the largest increase or the smallest decrease
+)
+
+// Pruning represents the strategy dropping states from a full open set.
+type Pruning uint8
+
+const (
+	PruneWorst  Pruning = iota // Drops the state with the highest estimated total cost (default)
+	PruneOldest                // Drops the state which was waiting in the open set the longest
+)
@@ -0,0 +1,106 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"context"
+	"log/slog"
+)
+
+// logSample is the number of expansions between two sampled expansions logged.
+const logSample = 1024
+
+// WithLogger logs the debug events of the search, such as a sample of the expanded nodes
+// and the plan found, into the logger. Nothing is formatted unless the logger is enabled
+// at the debug level, so it can be left configured in production.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// logDebug logs a debug event into the logger, if any and enabled at the debug level.
+func logDebug(logger *slog.Logger, msg string, attrs ...slog.Attr) {
+	if logger != nil && logger.Enabled(context.Background(), slog.LevelDebug) {
+		logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
+	}
+}
+
+// enabled returns whether the logger is enabled at the debug level.
+func enabled(logger *slog.Logger) bool {
+	return logger != nil && logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// logExpand logs every logSample-th node expanded by the search.
+func (s *Search) logExpand(node *State) {
+	if s.stats.Expand%logSample != 0 || !enabled(s.options.logger) {
+		return
+	}
+
+	logDebug(s.options.logger, "plan: node expanded",
+		slog.Uint64("expanded", s.stats.Expand),
+		slog.Int("depth", node.depth),
+		slog.Float64("cost", float64(node.stateCost)),
+		slog.Float64("heuristic", float64(node.heuristic)),
+		slog.String("state", node.String()),
+	)
+}
+
+// logFinish logs the outcome of the search.
+func (s *Search) logFinish(err error) {
+	switch {
+	case !enabled(s.options.logger):
+		return
+	case err != nil:
+		logDebug(s.options.logger, "plan: search failed",
+			slog.Uint64("expanded", s.stats.Expand),
+			slog.String("error", err.Error()),
+		)
+	default:
+		logDebug(s.options.logger, "plan: search done",
+			slog.Uint64("expanded", s.stats.Expand),
+			slog.Int("steps", len(s.dst)),
+			slog.Float64("cost", float64(costOf(s.dst))),
+		)
+	}
+}
+
+// SetLogger sets the logger receiving the lifecycle events of the executor at the debug
+// level, such as a plan being found, an action failing or a replan being triggered. The
+// searches of the planner are logged with the WithLogger option instead.
+func (e *Executor) SetLogger(logger *slog.Logger) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.logger = logger
+}
+
+// currentLogger returns the logger of the executor, if any.
+func (e *Executor) currentLogger() *slog.Logger {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.logger
+}
+
+// log logs the lifecycle event of the executor, if a logger is set.
+func (e *Executor) log(ev Event) {
+	logger := e.currentLogger()
+	if !enabled(logger) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 4)
+	if ev.Goal != nil {
+		attrs = append(attrs, slog.String("goal", ev.Goal.String()))
+	}
+	if ev.Plan != nil {
+		attrs = append(attrs, slog.Int("steps", len(ev.Plan)))
+	}
+	if ev.Action != nil {
+		attrs = append(attrs, slog.String("action", nameOf(ev.Action)))
+	}
+	if ev.Err != nil {
+		attrs = append(attrs, slog.String("error", ev.Err.Error()))
+	}
+	logDebug(logger, "plan: "+ev.Kind.String(), attrs...)
+}
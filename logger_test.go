@@ -0,0 +1,51 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	jump := &riskyAction{
+		testAction: testAction{name: "Jump", cost: 1, require: StateOf("A", "bridge"), outcome: StateOf("!A", "C")},
+		risk:       0.5,
+	}
+
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	planner, err := NewPlanner([]Action{jump, move("A->B", 2), move("B->C", 2)}, WithLogger(logger))
+	assert.NoError(t, err)
+
+	world := StateOf("A", "bridge")
+	executor := NewExecutor(planner, RetryPolicy{MaxReplans: 1})
+	executor.SetLogger(logger)
+	assert.NoError(t, executor.Run(func() *State { return world.Clone() }, StateOf("C"), func(a Action) error {
+		if a == jump {
+			assert.NoError(t, world.Del("bridge"))
+			return errors.New("bridge collapsed")
+		}
+
+		_, outcome := a.Simulate(world)
+		return world.Apply(outcome)
+	}))
+
+	log := out.String()
+	assert.Contains(t, log, `level=DEBUG msg="plan: search done" expanded=1 steps=1 cost=1`)
+	assert.Contains(t, log, `msg="plan: action failed" goal="{C=100}" action=Jump error="bridge collapsed"`)
+	assert.Contains(t, log, `msg="plan: replan triggered" replans=1`)
+	assert.Contains(t, log, `msg="plan: goal reached"`)
+
+	// Nothing is logged above the debug level
+	out.Reset()
+	_, err = Plan(StateOf("A"), StateOf("C"), []Action{move("A->C")},
+		WithLogger(slog.New(slog.NewTextHandler(&out, nil))))
+	assert.NoError(t, err)
+	assert.Empty(t, out.String())
+}
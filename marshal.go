@@ -0,0 +1,68 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "encoding/json"
+
+// planDocument represents the JSON document of a plan.
+type planDocument struct {
+	Start []string   `json:"start"` // The rules of the start state
+	Steps []planStep `json:"steps"` // The steps of the plan
+	Cost  float32    `json:"cost"`  // The total cost of the plan
+}
+
+// planStep represents a single step of the JSON document of a plan.
+type planStep struct {
+	Action string   `json:"action"` // The name of the action
+	Cost   float32  `json:"cost"`   // The cost of the action
+	Total  float32  `json:"total"`  // The cost of the plan up to and including the step
+	State  []string `json:"state"`  // The rules of the predicted state after the step
+}
+
+// MarshalPlan encodes the plan as a JSON document with, for every step, the name and the
+// cost of the action along with the state predicted after it, by applying the outcomes of
+// the actions in order from the start state. It is meant for logging, analytics and
+// debugging tools.
+func MarshalPlan(plan []Action, start *State) ([]byte, error) {
+	current := start.Flatten()
+	require, outcome := StateOf(), StateOf()
+	defer current.release()
+	defer require.release()
+	defer outcome.release()
+
+	doc := planDocument{
+		Start: rulesOf(current),
+		Steps: make([]planStep, 0, len(plan)),
+	}
+
+	for i, action := range plan {
+		_, o := simulate(action, current, nil, require, outcome)
+		if err := current.Apply(o); err != nil {
+			return nil, &StepError{Step: i, Action: action, Err: err}
+		}
+
+		if err := advance(current, action, OverflowSaturate); err != nil {
+			return nil, &StepError{Step: i, Action: action, Err: err}
+		}
+
+		doc.Cost += action.Cost()
+		doc.Steps = append(doc.Steps, planStep{
+			Action: nameOf(action),
+			Cost:   action.Cost(),
+			Total:  doc.Cost,
+			State:  rulesOf(current),
+		})
+	}
+
+	return json.Marshal(doc)
+}
+
+// rulesOf returns the string representation of every rule of the state.
+func rulesOf(state *State) []string {
+	out := make([]string, 0, len(state.vx))
+	for _, r := range state.vx {
+		out = append(out, r.String())
+	}
+	return out
+}
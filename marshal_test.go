@@ -0,0 +1,32 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalPlan(t *testing.T) {
+	start := StateOf("A", "hunger=80")
+	plan := []Action{
+		move("A->B"),
+		actionOf("Eat", 2, StateOf("B"), StateOf("hunger-50")),
+	}
+
+	out, err := MarshalPlan(plan, start)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"start": ["A=100", "hunger=80"],
+		"steps": [
+			{"action": "A->B", "cost": 1, "total": 1, "state": ["B=100", "A=0", "hunger=80"]},
+			{"action": "Eat", "cost": 2, "total": 3, "state": ["B=100", "A=0", "hunger=30"]}
+		],
+		"cost": 3
+	}`, string(out))
+
+	// The start state is not modified
+	assert.Equal(t, StateOf("A", "hunger=80").Hash(), start.Hash())
+}
@@ -0,0 +1,100 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "sync"
+
+// SmartObject represents an object of the world, such as a door, an oven or a chest, which
+// advertises the actions it affords to the agents nearby, instead of every agent knowing
+// every action of the world upfront.
+type SmartObject interface {
+
+	// Affords returns the actions the object currently affords.
+	Affords() []Action
+}
+
+// ObjectFilter represents a predicate selecting the smart objects, typically the ones near
+// the agent planning.
+type ObjectFilter func(name string, object SmartObject) bool
+
+// ObjectRegistry represents the smart objects of the world, unique by name, which can be
+// safely registered and unregistered at runtime while other goroutines plan with them.
+type ObjectRegistry struct {
+	lock    sync.RWMutex
+	names   []string       // The names of the objects, in registration order
+	objects []SmartObject  // The objects, in registration order
+	index   map[string]int // The index of every object by name
+}
+
+// NewObjectRegistry creates a new, empty registry of smart objects.
+func NewObjectRegistry() *ObjectRegistry {
+	return &ObjectRegistry{
+		index: make(map[string]int, 16),
+	}
+}
+
+// Register registers the object under the name, replacing any object with the same name.
+func (r *ObjectRegistry) Register(name string, object SmartObject) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if i, ok := r.index[name]; ok {
+		r.objects[i] = object
+		return
+	}
+
+	r.index[name] = len(r.objects)
+	r.names = append(r.names, name)
+	r.objects = append(r.objects, object)
+}
+
+// Unregister removes the object with the name and returns whether it was found.
+func (r *ObjectRegistry) Unregister(name string) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	i, ok := r.index[name]
+	if !ok {
+		return false
+	}
+
+	r.names = append(r.names[:i], r.names[i+1:]...)
+	r.objects = append(r.objects[:i], r.objects[i+1:]...)
+	delete(r.index, name)
+	for j := i; j < len(r.names); j++ {
+		r.index[r.names[j]] = j
+	}
+	return true
+}
+
+// Len returns the number of objects registered.
+func (r *ObjectRegistry) Len() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return len(r.objects)
+}
+
+// Actions returns the actions afforded by the objects for which the predicate returns
+// true, typically the objects near the agent, in registration order. A nil predicate
+// selects every object.
+func (r *ObjectRegistry) Actions(near ObjectFilter) []Action {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	var out []Action
+	for i, object := range r.objects {
+		if near == nil || near(r.names[i], object) {
+			out = append(out, object.Affords()...)
+		}
+	}
+	return out
+}
+
+// WithSmartObjects adds the actions afforded by the objects of the registry for which the
+// predicate returns true, typically the objects near the agent, to the actions of the
+// search. The objects are queried once when the search starts.
+func WithSmartObjects(registry *ObjectRegistry, near ObjectFilter) Option {
+	return func(o *options) {
+		o.objects = registry
+		o.near = near
+	}
+}
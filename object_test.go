@@ -0,0 +1,58 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmartObjects(t *testing.T) {
+	registry := NewObjectRegistry()
+	registry.Register("oven", &testObject{x: 1, actions: []Action{
+		actionOf("Bake", 2, StateOf("dough"), StateOf("!dough", "bread")),
+	}})
+	registry.Register("chest", &testObject{x: 50, actions: []Action{
+		actionOf("Loot", 1, StateOf(), StateOf("bread")),
+	}})
+	assert.Equal(t, 2, registry.Len())
+
+	near := func(x float32) ObjectFilter {
+		return func(_ string, object SmartObject) bool {
+			d := object.(*testObject).x - x
+			return d*d < 100
+		}
+	}
+
+	// Only the objects near the agent afford their actions
+	start, goal := StateOf("dough"), StateOf("bread")
+	_, err := Plan(start, goal, nil)
+	assert.Error(t, err)
+
+	plan, err := Plan(start, goal, nil, WithSmartObjects(registry, near(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bake"}, planOf(plan))
+
+	plan, err = Plan(start, goal, nil, WithSmartObjects(registry, near(45)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Loot"}, planOf(plan))
+
+	// The objects can be removed at runtime
+	assert.True(t, registry.Unregister("oven"))
+	assert.False(t, registry.Unregister("oven"))
+	assert.Len(t, registry.Actions(nil), 1)
+
+	_, err = Plan(start, goal, nil, WithSmartObjects(registry, near(0)))
+	assert.Error(t, err)
+}
+
+type testObject struct {
+	x       float32
+	actions []Action
+}
+
+func (o *testObject) Affords() []Action {
+	return o.actions
+}
@@ -0,0 +1,349 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"io"
+	"log/slog"
+)
+
+// Option represents an option that can be used to configure the planner.
+type Option func(*options)
+
+// defaultCapacity is the default initial capacity of the heap and the visited set.
+const defaultCapacity = 32
+
+// options represents the configuration of a single planning run.
+type options struct {
+	counters      *Counters         // Optional profiling counters
+	heapCapacity  int               // Initial capacity of the open set (heap)
+	visitCapacity int               // Initial capacity of the visited set
+	poolLimit     int               // Maximum graph size retained by the pool, 0 for unbounded
+	noPool        bool              // Whether the graph pooling is disabled
+	recover       bool              // Whether panics are recovered into errors
+	overflow      Overflow          // Behavior of the effects overflowing the range
+	waypoints     []*State          // Intermediate goals to satisfy in order
+	filter        func(Action) bool // Predicate selecting the actions to consider
+	budget        float32           // Maximum total cost of the plan, 0 for unlimited
+	invariant     *State            // Conditions which must hold in every state of the plan
+	forbidden     []*State          // Conditions which must never hold in any state of the plan
+	weight        float32           // Weight of the heuristic, 1 for a regular A* search
+	previous      []Action          // The previous plan of the agent, preferred by the search
+	discount      float32           // Fraction of the cost discounted for the previous plan
+	weights       []float32         // Weights of the costs of multi-cost actions
+	lexical       bool              // Whether the costs are compared lexicographically
+	caps          []Resource        // Maximum amounts of the resources the plan may consume
+	success       float32           // Minimum probability of the plan succeeding, 0 for any
+	seed          uint64            // Seed of the tie-breaking between equal costs, 0 for none
+	jitter        float32           // Maximum fraction of the action costs added as noise
+	trace         io.Writer         // Destination of the timeline of the search, if any
+	heatmap       *Heatmap          // Optional tallies of the facts read and written
+	breaks        []breakpoint      // Breakpoints pausing the search
+	final         *State            // Destination of the predicted final state, if any
+	record        bool              // Whether the predicted states of every step are recorded
+	deadends      *DeadEnds         // Optional tallies of the dead ends and their blockers
+	model         CostModel         // Optional learned model of the costs of the actions
+	hashSeed      uint64            // Seed of the hashes of the visited states, 0 for the default
+	bloomSize     int               // Expected number of states of the Bloom filter, 0 if disabled
+	bloomRate     float64           // False-positive rate of the Bloom filter
+	openLimit     int               // Maximum number of states of the open set, 0 for unlimited
+	pruning       Pruning           // Strategy dropping states from a full open set
+	stance        Stance            // Outcome assumed for the uncertain effects
+	personality   Personality       // Multipliers of the cost of the tagged actions
+	provider      CostProvider      // Optional external provider of the cost of the moves
+	logger        *slog.Logger      // Optional logger of the debug events of the search
+	analytics     *Analytics        // Optional tallies of the actions of the plans found
+	objects       *ObjectRegistry   // Optional smart objects affording additional actions
+	near          ObjectFilter      // Predicate selecting the smart objects near the agent
+	include       []string          // Names of the actions the plan must include
+	avoid         []string          // Names of the actions the plan must never include
+}
+
+// optionsOf creates the planner configuration from the provided options.
+func optionsOf(opts []Option) options {
+	if len(opts) == 0 { // Avoid allocating in the common case
+		return options{
+			heapCapacity:  defaultCapacity,
+			visitCapacity: defaultCapacity,
+			weight:        1,
+		}
+	}
+
+	return with(options{
+		heapCapacity:  defaultCapacity,
+		visitCapacity: defaultCapacity,
+		weight:        1,
+	}, opts)
+}
+
+// with applies the options on top of an existing configuration.
+func with(base options, opts []Option) options {
+	if len(opts) == 0 {
+		return base
+	}
+
+	o := &base
+	for _, opt := range opts {
+		opt(o)
+	}
+	return *o
+}
+
+// WithCounters collects the profiling counters of the planning run into the
+// provided destination once the planner returns. The destination is overwritten by
+// the run and owned by it, so concurrent runs must each pass their own, rather than
+// share one, for example given to NewPlanner.
+func WithCounters(dst *Counters) Option {
+	return func(o *options) {
+		o.counters = dst
+	}
+}
+
+// WithCapacity sets the initial capacity of the open set (heap) and of the visited set,
+// which avoids growing them repeatedly during very large searches.
+func WithCapacity(heap, visit int) Option {
+	return func(o *options) {
+		o.heapCapacity = max(heap, 0)
+		o.visitCapacity = max(visit, 0)
+	}
+}
+
+// WithPoolLimit sets the maximum number of states a search graph may hold for it to be
+// returned into the pool. Larger graphs are discarded so the pool memory stays bounded.
+func WithPoolLimit(limit int) Option {
+	return func(o *options) {
+		o.poolLimit = limit
+	}
+}
+
+// WithoutPool disables pooling of the search graph entirely, a fresh one is allocated
+// for every planning run and left to the garbage collector afterwards.
+func WithoutPool() Option {
+	return func(o *options) {
+		o.noPool = true
+	}
+}
+
+// WithRecover recovers from any panic during planning, such as one from a faulty
+// action, and returns it as a *PanicError instead of crashing the process.
+func WithRecover() Option {
+	return func(o *options) {
+		o.recover = true
+	}
+}
+
+// WithOverflow sets the behavior when an effect pushes a fact outside of its range.
+// By default, values saturate, which may hide domain bugs such as a double-applied buff.
+func WithOverflow(overflow Overflow) Option {
+	return func(o *options) {
+		o.overflow = overflow
+	}
+}
+
+// WithWaypoints sets an ordered list of intermediate goals which must be satisfied in
+// sequence before the final goal, for example "get key", then "open vault" and only
+// then "escape". The resulting plan is the concatenation of the plans of each segment.
+func WithWaypoints(goals ...*State) Option {
+	return func(o *options) {
+		o.waypoints = goals
+	}
+}
+
+// WithActionFilter narrows the set of actions considered by a single planning run to
+// the ones for which the predicate returns true, for example to disable loud actions
+// while sneaking, without rebuilding the set of actions.
+func WithActionFilter(predicate func(Action) bool) Option {
+	return func(o *options) {
+		o.filter = predicate
+	}
+}
+
+// WithBudget sets the maximum total cost of the plan, for example the stamina or action
+// points available. Branches exceeding it are pruned and, if no plan fits within the
+// budget, the planner returns an ErrBudget error.
+func WithBudget(cost float32) Option {
+	return func(o *options) {
+		o.budget = cost
+	}
+}
+
+// WithInvariant sets the conditions which must hold in every intermediate state of the
+// plan, such as "health>0" or "oxygen>10". Branches entering a state which violates any
+// of them are pruned, so the plan never routes through lethal states.
+func WithInvariant(invariant *State) Option {
+	return func(o *options) {
+		o.invariant = invariant
+	}
+}
+
+// WithForbidden sets the taboo conditions the plan must never enter, such as "alarm=100".
+// Unlike preconditions, these are checked against every state after applying the outcomes
+// of an action, and the branches entering a state matching any of them are pruned.
+func WithForbidden(states ...*State) Option {
+	return func(o *options) {
+		o.forbidden = states
+	}
+}
+
+// WithWeight sets the weight of the heuristic, turning the search into a weighted A*.
+// Weights above 1 find a plan faster by expanding fewer nodes, at the expense of its
+// cost being up to weight times the one of the optimal plan.
+func WithWeight(weight float32) Option {
+	return func(o *options) {
+		o.weight = max(weight, 1)
+	}
+}
+
+// WithPrevious biases the search toward reusing the previous plan of the agent, so minor
+// changes of the world produce minimally different plans and agents do not flip-flop. The
+// cost of the actions matching a prefix of the previous plan is discounted by the fraction
+// between 0 and 1. Actions are compared by identity, hence the same instances must be used.
+func WithPrevious(plan []Action, discount float32) Option {
+	return func(o *options) {
+		o.previous = plan
+		o.discount = min(max(discount, 0), 1)
+	}
+}
+
+// WithCostWeights sets the weights of the costs of multi-cost actions, whose cost becomes
+// the weighted sum of their costs. For example, a sneaking agent may weigh noise heavily
+// while a fleeing one only cares about time. Actions with a single cost use the first weight.
+func WithCostWeights(weights ...float32) Option {
+	return func(o *options) {
+		o.weights = weights
+		o.lexical = false
+	}
+}
+
+// WithLexicographic compares the costs of multi-cost actions lexicographically, the first
+// cost being the most important one and the following ones only breaking the ties. The
+// heuristic and the budget only apply to the first cost.
+func WithLexicographic() Option {
+	return func(o *options) {
+		o.lexical = true
+		o.weights = nil
+	}
+}
+
+// WithResourceCap sets the maximum net amount of the resource the plan may consume at any
+// point, such as the ammo carried by the agent. Branches exceeding it are pruned.
+func WithResourceCap(name string, limit float32) Option {
+	return func(o *options) {
+		o.caps = append(o.caps[:len(o.caps):len(o.caps)], Resource{
+			Name:   name,
+			Amount: limit,
+		})
+	}
+}
+
+// WithMinSuccess sets the minimum probability of the plan succeeding, for example 0.8 for
+// an overall success of at least 80%, given the risks of the actions. Riskier branches are
+// pruned, even if they are cheaper.
+func WithMinSuccess(probability float32) Option {
+	return func(o *options) {
+		o.success = min(max(probability, 0), 1)
+	}
+}
+
+// WithSeed breaks the ties between plans of equal cost pseudo-randomly using the seed, for
+// example a per-agent one, so a crowd of identical agents with the same goal does not
+// produce identical plans. The plans remain reproducible for the same seed.
+func WithSeed(seed uint64) Option {
+	return func(o *options) {
+		o.seed = seed
+	}
+}
+
+// WithJitter perturbs the cost of every action by a small pseudo-random noise, up to the
+// fraction of the cost, so equally good plans vary between agents and runs. The noise is
+// derived from the seed set with WithSeed, hence remains reproducible for replays.
+func WithJitter(fraction float32) Option {
+	return func(o *options) {
+		o.jitter = max(fraction, 0)
+	}
+}
+
+// WithTrace records the timeline of the search, with every node expansion, heap operation
+// and action simulation, and writes it into the destination in the Chrome trace event
+// format once the search completes. The trace can be opened with chrome://tracing or
+// Perfetto to investigate the performance of large domains, but is expensive to record.
+func WithTrace(dst io.Writer) Option {
+	return func(o *options) {
+		o.trace = dst
+	}
+}
+
+// WithHeatmap tallies how often every fact is read by the requirements and the goal and
+// written by the outcomes during the search into the provided heatmap, which accumulates
+// the tallies across the searches it is passed to.
+func WithHeatmap(dst *Heatmap) Option {
+	return func(o *options) {
+		o.heatmap = dst
+	}
+}
+
+// WithBreakpoint pauses the search when the breakpoint is hit, calling the function with
+// the node reached, for example to find out why an action is never picked. The search is
+// resumed once the function returns true, or stopped with an ErrStopped error otherwise.
+func WithBreakpoint(bp Breakpoint, fn func(Hit) bool) Option {
+	return func(o *options) {
+		o.breaks = append(o.breaks[:len(o.breaks):len(o.breaks)], breakpoint{
+			Breakpoint: bp,
+			fn:         fn,
+		})
+	}
+}
+
+// WithFinal writes the predicted final state of the world, once every action of the plan is
+// performed, into the destination when a plan is found. This allows to inspect the side
+// effects of the plan, such as how tired the agent will be, without simulating it again.
+func WithFinal(dst *State) Option {
+	return func(o *options) {
+		o.final = dst
+	}
+}
+
+// WithDeadEnds tallies the states reached during the search from which no action is
+// applicable into the provided destination, along with the preconditions which blocked
+// the actions there. The destination accumulates the tallies across the searches.
+func WithDeadEnds(dst *DeadEnds) Option {
+	return func(o *options) {
+		o.deadends = dst
+	}
+}
+
+// WithHashSeed sets the seed of the hashes identifying the states visited by the search. By
+// default, states are identified by their hash, so two distinct states which happen to
+// collide are treated as one for every search. A seed, for example one per domain, makes
+// such collisions differ between seeds, and lockstep peers sharing a seed explore the
+// same states. Seeded hashes are slower to compute, and 0 restores the default. The seed
+// does not apply to the facts, which are identified by the hash of their name across all
+// domains, so two names colliding remain the same fact regardless of the seed.
+func WithHashSeed(seed uint64) Option {
+	return func(o *options) {
+		o.hashSeed = seed
+	}
+}
+
+// WithOpenLimit sets the maximum number of states in the open set of the search. Once full,
+// a state is dropped according to the strategy every time a new one is added, so domains
+// which would otherwise grow the memory without bound degrade into an approximate search
+// instead. The plans found are no longer guaranteed to be optimal, and a plan may not be
+// found at all if the states leading to it were dropped.
+func WithOpenLimit(size int, strategy Pruning) Option {
+	return func(o *options) {
+		o.openLimit = max(size, 0)
+		o.pruning = strategy
+	}
+}
+
+// WithStance sets the outcome the planner assumes for the uncertain effects, such as
+// "food+5..15". By default, the middle of the range is assumed, while a pessimistic stance
+// assumes the smallest increase or the largest decrease so that the plans remain valid
+// whenever the effects underdeliver, and an optimistic one assumes the opposite.
+func WithStance(stance Stance) Option {
+	return func(o *options) {
+		o.stance = stance
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// Personality represents the preferences of an agent, as multipliers of the cost of the
+// actions carrying a tag, for example a coward with 3 for the "combat" actions or a lazy
+// agent with 2 for the "work" ones. This gives behavioral variety to agents sharing the
+// same domain. The multipliers of an action carrying several tags are combined.
+type Personality map[string]float32
+
+// WithPersonality multiplies the cost of the actions implementing TaggedAction by the
+// multipliers of their tags in the personality of the agent during the search.
+func WithPersonality(personality Personality) Option {
+	return func(o *options) {
+		o.personality = personality
+	}
+}
+
+// factor returns the multiplier of the cost of the action.
+func (p Personality) factor(action Action) float32 {
+	tagged, ok := action.(TaggedAction)
+	if !ok {
+		return 1
+	}
+
+	factor := float32(1)
+	for _, tag := range tagged.Tags() {
+		if m, ok := p[tag]; ok {
+			factor *= max(m, 0)
+		}
+	}
+	return factor
+}
+
+// factors returns the multipliers of the cost of the actions, nil if there is no personality.
+func (p Personality) factors(actions []Action) []float32 {
+	if len(p) == 0 {
+		return nil
+	}
+
+	out := make([]float32, len(actions))
+	for i, action := range actions {
+		out[i] = p.factor(action)
+	}
+	return out
+}
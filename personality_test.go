@@ -0,0 +1,40 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonality(t *testing.T) {
+	start, goal := StateOf("enemy", "!safe"), StateOf("safe")
+	actions := []Action{
+		&taggedAction{testAction: *actionOf("fight", 2, StateOf("enemy"), StateOf("!enemy", "safe")).(*testAction), tags: []string{"combat"}},
+		&taggedAction{testAction: *actionOf("flee", 4, StateOf("enemy"), StateOf("safe")).(*testAction), tags: []string{"movement"}},
+	}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fight"}, planOf(plan))
+
+	// A coward avoids fighting, unless fleeing is even more costly
+	plan, err = Plan(start, goal, actions, WithPersonality(Personality{"combat": 3}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"flee"}, planOf(plan))
+
+	plan, err = Plan(start, goal, actions, WithPersonality(Personality{"combat": 3, "movement": 2}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fight"}, planOf(plan))
+}
+
+type taggedAction struct {
+	testAction
+	tags []string
+}
+
+func (a *taggedAction) Tags() []string {
+	return a.tags
+}
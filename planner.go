@@ -4,7 +4,8 @@
 package goap
 
 import (
-	"errors"
+	"fmt"
+	"math"
 	"sync"
 )
 
@@ -21,86 +22,174 @@ type Action interface {
 	Cost() float32
 }
 
-// Plan finds a plan to reach the goal from the start state using the provided actions.
-func Plan(start, goal *State, actions []Action) ([]Action, error) {
-	start = start.Clone()
-	start.node = node{
-		heuristic: start.Distance(goal),
+// StaticAction can be optionally implemented by actions whose requirements and outcomes
+// depend only on the action itself and not on the current state. The planner then only
+// calls Simulate once per search and reuses its result for every expansion.
+type StaticAction interface {
+
+	// Static returns whether the requirements and outcomes of the action are static.
+	Static() bool
+}
+
+// ScratchSimulator can be optionally implemented by actions whose requirements and
+// outcomes depend on the current state. Instead of building new states on every
+// expansion, the action writes them into the empty scratch states provided by the
+// planner, which are reused across expansions. Precompiled rules can be written
+// using AddRule() without any allocations.
+type ScratchSimulator interface {
+
+	// SimulateInto writes the requirements and outcomes given the current
+	// state (model) of the world into the provided scratch states.
+	SimulateInto(current, require, outcome *State)
+}
+
+// GoalSimulator can be optionally implemented by parameterized actions which orient their
+// outcomes toward the goal, such as "move toward target", instead of enumerating every
+// possible outcome. The goal is a read-only view, which is empty when planning for a
+// predicate goal or when the plan is simulated without any goal.
+type GoalSimulator interface {
+
+	// SimulateGoal returns requirements and outcomes given the current state (model)
+	// of the world and a read-only view of the goal of the current search segment.
+	SimulateGoal(current *State, goal View) (require, outcome *State)
+}
+
+// MultiCostAction can be optionally implemented by actions with several costs, such as
+// time, noise and risk, so that the trade-off between them can vary by situation. The
+// costs are combined per planning run with either WithCostWeights or WithLexicographic.
+type MultiCostAction interface {
+
+	// Costs returns the vector of costs of performing the action, up to MaxCosts.
+	Costs() []float32
+}
+
+// Counters represents the profiling counters collected during a single planning run,
+// which can be used to see where the planner spends its effort.
+type Counters struct {
+	Match    uint64 // Number of state matches performed
+	Apply    uint64 // Number of effects applied to a state
+	Distance uint64 // Number of heuristic (distance) evaluations
+	Clone    uint64 // Number of states cloned
+	Rehash   uint64 // Number of incremental state rehashes
+	Expand   uint64 // Number of nodes expanded
+	Branch   uint64 // Number of applicable actions, across all expanded nodes
+	DeadEnd  uint64 // Number of expanded nodes without any applicable action
+}
+
+// PanicError represents a panic which occurred during planning and was recovered,
+// typically from within a user-provided action.
+type PanicError struct {
+	Value  any    // The recovered panic value
+	Action Action // The action being evaluated, if any
+	State  string // The state being expanded, if any
+	Stack  []byte // The stack trace of the panic
+}
+
+// Error returns the error message.
+func (e *PanicError) Error() string {
+	if e.Action == nil {
+		return fmt.Sprintf("plan: panic during planning: %v", e.Value)
 	}
 
-	heap := acquireHeap()
-	heap.Push(start)
-	defer heap.Release()
+	return fmt.Sprintf("plan: panic in action '%s' on state %s: %v", nameOf(e.Action), e.State, e.Value)
+}
 
-	for heap.Len() > 0 {
-		current, _ := heap.Pop()
+// Planner represents a reusable planner, constructed once with a set of actions and
+// options. It is immutable and can be safely used by multiple goroutines.
+type Planner struct {
+	actions []Action
+	options options
+}
 
-		/*fmt.Printf("- (%d) %s, cost=%v, heuristic=%v, total=%v\n",
-		current.depth, current.action,
-		current.stateCost, current.heuristic, current.totalCost)*/
+// NewPlanner creates a new planner for the set of actions and the options.
+func NewPlanner(actions []Action, opts ...Option) (*Planner, error) {
+	if len(actions) > MaxActions {
+		return nil, fmt.Errorf("%w, %d actions provided", ErrTooManyActions, len(actions))
+	}
 
-		if current.depth >= maxDepth {
-			return reconstructPlan(current), nil
-		}
+	o := optionsOf(opts)
+	if o.filter != nil {
+		actions = filter(actions, o.filter)
+		o.filter = nil
+	}
 
-		// If we reached the goal, reconstruct the path.
-		done, err := current.Match(goal)
-		switch {
-		case err != nil:
-			return nil, err
-		case done:
-			return reconstructPlan(current), nil
-		}
+	return &Planner{
+		actions: actions,
+		options: o,
+	}, nil
+}
 
-		for _, action := range actions {
-			require, outcome := action.Simulate(current)
-			match, err := current.Match(require)
-			switch {
-			case err != nil:
-				return nil, err
-			case !match:
-				continue // Skip this action
-			}
+// Actions returns the set of actions of the planner.
+func (p *Planner) Actions() []Action {
+	return p.actions
+}
 
-			// Apply the outcome to the new state
-			newState := current.Clone()
-			if err := newState.Apply(outcome); err != nil {
-				return nil, err
-			}
+// Plan finds a plan to reach the goal from the start state. The options, if any,
+// are applied on top of the options of the planner for this call only.
+func (p *Planner) Plan(start, goal *State, opts ...Option) ([]Action, error) {
+	return run(nil, start, goalOf(goal), p.actions, with(p.options, opts))
+}
 
-			// Check if newState is already planned to be visited or if the newCost is lower
-			newCost := current.stateCost + action.Cost()
-			node, found := heap.Find(newState.Hash())
-			switch {
-			case !found:
-				heuristic := newState.Distance(goal)
-				newState.parent = current
-				newState.action = action
-				newState.heuristic = heuristic
-				newState.stateCost = newCost
-				newState.totalCost = newCost + heuristic
-				newState.depth = current.depth + 1
-				heap.Push(newState)
-
-			// In any of those cases, we need to release the new state
-			case found && !node.visited && newCost < node.stateCost:
-				node.parent = current
-				node.stateCost = newCost
-				node.totalCost = newCost + node.heuristic
-				heap.Fix(node) // Update the node's position in the heap
-				fallthrough
-			default: // The new state is already visited or the newCost is higher
-				newState.release()
-			}
-		}
+// PlanInto finds a plan to reach the goal from the start state and writes it into
+// the destination buffer, reusing its capacity when possible.
+func (p *Planner) PlanInto(dst []Action, start, goal *State, opts ...Option) ([]Action, error) {
+	return run(dst[:0], start, goalOf(goal), p.actions, with(p.options, opts))
+}
+
+// PlanFunc finds a plan to reach a predicate goal from the start state.
+func (p *Planner) PlanFunc(start *State, goal GoalFunc, opts ...Option) ([]Action, error) {
+	return run(nil, start, objective{fn: goal}, p.actions, with(p.options, opts))
+}
+
+// Plan finds a plan to reach the goal from the start state using the provided actions.
+func Plan(start, goal *State, actions []Action, opts ...Option) ([]Action, error) {
+	return PlanInto(nil, start, goal, actions, opts...)
+}
+
+// PlanInto finds a plan to reach the goal from the start state using the provided actions
+// and writes it into the destination buffer, reusing its capacity when possible.
+func PlanInto(dst []Action, start, goal *State, actions []Action, opts ...Option) ([]Action, error) {
+	return run(dst[:0], start, goalOf(goal), actions, optionsOf(opts))
+}
+
+// PlanFunc finds a plan to reach a predicate goal from the start state using the provided
+// actions. The distance returned by the predicate is used as the heuristic of the search,
+// hence it should never overestimate the remaining cost for the plan to be optimal.
+func PlanFunc(start *State, goal GoalFunc, actions []Action, opts ...Option) ([]Action, error) {
+	return run(nil, start, objective{fn: goal}, actions, optionsOf(opts))
+}
+
+// run configures and runs the search for a plan to reach the goal.
+func run(dst []Action, start *State, goal objective, actions []Action, o options) ([]Action, error) {
+	var search Search
+	search.init(dst, start, goal, actions, o)
+	for !search.Step(math.MaxInt) {
 	}
 
-	return nil, errors.New("no plan could be found to reach the goal")
+	if search.final != nil {
+		search.final.release()
+	}
+	return search.Result()
+}
+
+// filter returns the actions for which the predicate returns true.
+func filter(actions []Action, predicate func(Action) bool) []Action {
+	out := make([]Action, 0, len(actions))
+	for _, action := range actions {
+		if predicate(action) {
+			out = append(out, action)
+		}
+	}
+	return out
 }
 
 // reconstructPlan reconstructs the plan from the goal node to the start node.
-func reconstructPlan(goalNode *State) []Action {
-	plan := make([]Action, 0, int(goalNode.depth))
+func reconstructPlan(plan []Action, goalNode *State) []Action {
+	offset := len(plan)
+	if plan == nil || cap(plan) < offset+goalNode.depth {
+		plan = append(make([]Action, 0, offset+goalNode.depth), plan...)
+	}
+
 	for n := goalNode; n != nil; n = n.parent {
 		if n.action != nil { // The start node has no action
 			plan = append(plan, n.action)
@@ -108,7 +197,7 @@ func reconstructPlan(goalNode *State) []Action {
 	}
 
 	// Reverse the slice of actions because we traversed the nodes from goal to start
-	for i, j := 0, len(plan)-1; i < j; i, j = i+1, j-1 {
+	for i, j := offset, len(plan)-1; i < j; i, j = i+1, j-1 {
 		plan[i], plan[j] = plan[j], plan[i]
 	}
 	return plan
@@ -118,59 +207,275 @@ func reconstructPlan(goalNode *State) []Action {
 
 var graphs = sync.Pool{
 	New: func() any {
-		return &graph{
-			visit: make(map[uint32]*State, 32),
-			heap:  make([]*State, 0, 32),
-		}
+		return newGraph(defaultCapacity, defaultCapacity)
 	},
 }
 
+// newGraph creates a new graph with the specified heap and visited set capacities.
+func newGraph(heapCapacity, visitCapacity int) *graph {
+	return &graph{
+		visit:   make(map[uint32]*State, visitCapacity),
+		dist:    make(map[uint32]float32, visitCapacity),
+		heap:    make([]*State, 0, heapCapacity),
+		require: newState(0),
+		outcome: newState(0),
+		reserve: visitCapacity,
+	}
+}
+
 // Acquires a new instance of a heap
-func acquireHeap() *graph {
+func acquireHeap(o *options) *graph {
+	if o.noPool {
+		h := newGraph(o.heapCapacity, o.visitCapacity)
+		h.seed = o.hashSeed
+		h.filter(o)
+		return h
+	}
+
 	h := graphs.Get().(*graph)
+	if cap(h.heap) < o.heapCapacity {
+		h.heap = make([]*State, 0, o.heapCapacity)
+	}
+
 	h.heap = h.heap[:0]
+	h.current = nil
+	h.action = nil
+	h.limit = o.poolLimit
+	h.seed = o.hashSeed
+	h.pushes = 0
+	h.pooled = true
+	h.filter(o)
+
+	// Maps can't be grown in place, so a larger visited set is allocated anew
+	if h.reserve < o.visitCapacity {
+		h.visit = make(map[uint32]*State, o.visitCapacity)
+		h.dist = make(map[uint32]float32, o.visitCapacity)
+		h.reserve = o.visitCapacity
+	}
+
 	clear(h.visit)
+	clear(h.dist)
 	return h
 }
 
+// filter prepares the Bloom filter of the visited states, if enabled.
+func (h *graph) filter(o *options) {
+	h.dropped = false
+	switch {
+	case o.bloomSize == 0:
+		h.bloom = nil
+	case h.bloom != nil && h.bloom.limit == o.bloomSize && h.rate == o.bloomRate:
+		h.bloom.reset()
+	default:
+		h.bloom = newBloom(o.bloomSize, o.bloomRate)
+		h.rate = o.bloomRate
+	}
+}
+
 // Release the instance back to the pool
 func (h *graph) Release() {
 	for _, s := range h.visit {
 		s.release()
 	}
-	graphs.Put(h)
+
+	clear(h.sims)
+	h.goal = nil
+	h.current = nil
+	h.action = nil
+
+	// Large graphs are left to the garbage collector so the pool does not retain them
+	if h.pooled && (h.limit <= 0 || (cap(h.heap) <= h.limit && len(h.visit) <= h.limit && len(h.dist) <= h.limit)) {
+		graphs.Put(h)
+	}
+}
+
+// ResetPools discards the graphs and the states retained by the internal pools and clears
+// the cache of the parsed rules, so that none of them is reused once a simulation restarts
+// from a checkpoint, typically followed by WarmPools(). The pools remain managed by the
+// runtime, which drops and reuses pooled items as it sees fit, so this does not make the
+// allocations identical to the ones of a fresh process. It must not be called while
+// planning.
+func ResetPools() {
+	graphs = sync.Pool{New: graphs.New}
+	pool = sync.Pool{New: pool.New}
+	ruleCache.Reset()
+}
+
+// WarmPools preallocates the graphs of the searches and the states into the internal
+// pools, so that the first searches do not allocate them.
+func WarmPools(graphCount, stateCount int) {
+	for i := 0; i < graphCount; i++ {
+		graphs.Put(newGraph(defaultCapacity, defaultCapacity))
+	}
+	for i := 0; i < stateCount; i++ {
+		pool.Put(pool.New())
+	}
 }
 
 // ------------------------------------ Heap ------------------------------------
 
 type graph struct {
-	visit map[uint32]*State
-	heap  []*State
+	visit   map[uint32]*State
+	dist    map[uint32]float32 // Memoized heuristic of the states, by hash
+	heap    []*State
+	require *State       // Scratch state for the requirements
+	outcome *State       // Scratch state for the outcomes
+	current *State       // The state currently being expanded
+	action  Action       // The action currently being evaluated
+	sims    []simulation // Simulation cache, one per action
+	goal    *State       // The goal of the search, for the goal-aware actions
+	limit   int          // Maximum size of the graph to return into the pool
+	seed    uint64       // Seed of the keys of the visited set, 0 for the state hash
+	bloom   *bloom       // Bloom filter in front of the visited set, if enabled
+	rate    float64      // False-positive rate of the Bloom filter
+	dropped bool         // Whether some states are only tracked by the Bloom filter
+	pushes  uint32       // Number of states pushed into the heap
+	pooled  bool         // Whether the graph was acquired from the pool
+	reserve int          // Capacity the visited set was allocated with
+}
+
+// simulation represents the simulation details of a single action.
+type simulation struct {
+	scratch ScratchSimulator // The action as a scratch simulator, if supported
+	aware   GoalSimulator    // The action as a goal simulator, if supported
+	view    ViewSimulator    // The action as a view simulator, if supported
+	require *State           // The cached requirements of a static action
+	outcome *State           // The cached outcomes of a static action
+	static  bool             // Whether the action is static
+	cached  bool             // Whether the requirements and outcomes are cached
+}
+
+// prepare prepares the simulation cache for the set of actions and the goal.
+func (h *graph) prepare(actions []Action, goal *State) {
+	h.goal = goal
+	h.sims = h.sims[:0]
+	for _, action := range actions {
+		scratch, _ := action.(ScratchSimulator)
+		aware, _ := action.(GoalSimulator)
+		view, _ := action.(ViewSimulator)
+		static, _ := action.(StaticAction)
+		h.sims = append(h.sims, simulation{
+			scratch: scratch,
+			aware:   aware,
+			view:    view,
+			static:  scratch == nil && aware == nil && view == nil && static != nil && static.Static(),
+		})
+	}
+}
+
+// simulate simulates the i-th action, using the scratch states if the action supports
+// it, or the cached requirements and outcomes if the action is static.
+func (h *graph) simulate(i int, action Action, current *State) (require, outcome *State) {
+	sim := &h.sims[i]
+	switch {
+	case sim.cached:
+		return sim.require, sim.outcome
+	case sim.static:
+		sim.require, sim.outcome = action.Simulate(current)
+		sim.cached = true
+		return sim.require, sim.outcome
+	case sim.scratch != nil:
+		h.require.reset()
+		h.outcome.reset()
+		sim.scratch.SimulateInto(current, h.require, h.outcome)
+		return h.require, h.outcome
+	case sim.aware != nil:
+		return sim.aware.SimulateGoal(current, ViewOf(h.goal))
+	case sim.view != nil:
+		return sim.view.SimulateView(ViewOf(current))
+	default:
+		return action.Simulate(current)
+	}
+}
+
+// simulate simulates the action toward the goal, which may be nil, and returns its
+// requirements and outcomes. If the action supports it, these are written into the
+// provided scratch states.
+func simulate(action Action, current, goal, require, outcome *State) (*State, *State) {
+	switch v := action.(type) {
+	case ScratchSimulator:
+		require.reset()
+		outcome.reset()
+		v.SimulateInto(current, require, outcome)
+		return require, outcome
+	case GoalSimulator:
+		return v.SimulateGoal(current, ViewOf(goal))
+	case ViewSimulator:
+		return v.SimulateView(ViewOf(current))
+	default:
+		return action.Simulate(current)
+	}
 }
 
 // Len returns the number of elements in the heap.
 func (h *graph) Len() int { return len(h.heap) }
 
 // Less reports whether the element with
-func (h *graph) Less(i, j int) bool { return h.heap[i].totalCost < h.heap[j].totalCost }
+func (h *graph) Less(i, j int) bool {
+	a, b := h.heap[i], h.heap[j]
+	if a.totalCost != b.totalCost {
+		return a.totalCost < b.totalCost
+	}
+	if a.extra != b.extra {
+		return a.extra.less(b.extra)
+	}
+	return a.tie < b.tie
+}
 
 // Swap swaps the elements with indexes i and j.
-func (h *graph) Swap(i, j int) { h.heap[i], h.heap[j] = h.heap[j], h.heap[i] }
+func (h *graph) Swap(i, j int) {
+	h.heap[i], h.heap[j] = h.heap[j], h.heap[i]
+	h.heap[i].index = i
+	h.heap[j].index = j
+}
 
-// Push pushes the element x onto the heap.
-// The complexity is O(log n) where n = h.Len().
+// Push pushes the state into the heap, its key must be set beforehand. Past the limit of the
+// Bloom filter, the state is no longer tracked by the visited set, and is left to the
+// garbage collector rather than returned into the pool once the graph is released.
 func (h *graph) Push(v *State) {
+	h.pushes++
+	v.seq = h.pushes
 	v.index = h.Len()
 	h.heap = append(h.heap, v)
 	h.up(h.Len() - 1)
-	h.visit[v.Hash()] = v
+	if h.bloom == nil {
+		h.visit[v.key] = v
+		return
+	}
+
+	h.bloom.Add(v.key)
+	if len(h.visit) < h.bloom.limit {
+		h.visit[v.key] = v
+	} else {
+		h.dropped = true
+	}
 }
 
-func (h *graph) Find(hash uint32) (*State, bool) {
-	v, ok := h.visit[hash]
+// Find returns the state with the key, if it was already pushed and is still tracked.
+func (h *graph) Find(key uint32) (*State, bool) {
+	if h.bloom != nil && !h.bloom.Contains(key) {
+		return nil, false
+	}
+
+	v, ok := h.visit[key]
 	return v, ok
 }
 
+// Seen returns whether a state which is not found may still have been pushed, as it is
+// only tracked by the Bloom filter.
+func (h *graph) Seen(key uint32) bool {
+	return h.dropped && h.bloom.Contains(key)
+}
+
+// keyOf returns the key of the state in the visited set, which is the hash of the state
+// unless a seed is set.
+func (h *graph) keyOf(state *State) uint32 {
+	if h.seed == 0 {
+		return state.Hash()
+	}
+	return state.seededHash(h.seed)
+}
+
 // Pop removes and returns the minimum element (according to Less) from the heap.
 // The complexity is O(log n) where n = h.Len().
 // Pop is equivalent to Remove(h, 0).
@@ -195,6 +500,40 @@ func (h *graph) Fix(v *State) {
 	}
 }
 
+// Remove removes and returns the element at index i from the heap. It is marked as visited,
+// so that it is neither expanded nor updated afterwards.
+func (h *graph) Remove(i int) *State {
+	if n := h.Len() - 1; n != i {
+		h.Swap(i, n)
+		if !h.down(i, n) {
+			h.up(i)
+		}
+	}
+	return h.pop()
+}
+
+// victim returns the index of the state to drop from the heap according to the strategy.
+func (h *graph) victim(strategy Pruning) int {
+	switch strategy {
+	case PruneOldest:
+		at := 0
+		for i, v := range h.heap {
+			if v.seq < h.heap[at].seq {
+				at = i
+			}
+		}
+		return at
+	default: // The worst state is one of the leaves
+		at := h.Len() / 2
+		for i := at + 1; i < h.Len(); i++ {
+			if h.Less(at, i) {
+				at = i
+			}
+		}
+		return at
+	}
+}
+
 func (h *graph) pop() *State {
 	old := h.heap
 	n := len(old)
@@ -202,7 +541,6 @@ func (h *graph) pop() *State {
 	node.visited = true
 
 	h.heap = old[0 : n-1]
-	h.visit[node.Hash()] = node
 	return node
 }
 
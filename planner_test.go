@@ -4,6 +4,9 @@
 package goap
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -62,6 +65,24 @@ func BenchmarkPlan(b *testing.B) {
 		}
 	})
 
+	b.Run("deep-into", func(b *testing.B) {
+		start := StateOf("hunger=80", "!food", "!tired")
+		goal := StateOf("food>80")
+		actions := []Action{
+			actionOf("Eat", 1.0, StateOf("food>0"), StateOf("hunger-50", "food-5")),
+			actionOf("Forage", 1.0, StateOf("tired<50"), StateOf("tired+20", "food+10", "hunger+5")),
+			actionOf("Sleep", 1.0, StateOf("tired>30"), StateOf("tired-50")),
+		}
+
+		plan := make([]Action, 0, 32)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var err error
+			plan, err = PlanInto(plan, start, goal, actions)
+			assert.NoError(b, err)
+		}
+	})
+
 	b.Run("maze", func(b *testing.B) {
 		start := StateOf("A")
 		goal := StateOf("Z")
@@ -142,8 +163,645 @@ func TestNoPlanFound(t *testing.T) {
 	assert.Nil(t, plan)
 }
 
+func TestCounters(t *testing.T) {
+	var stats Counters
+	plan, err := Plan(StateOf("A", "B"), StateOf("C", "D"),
+		[]Action{move("A->C"), move("A->D"), move("B->C"), move("B->D")},
+		WithCounters(&stats),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C", "B->D"}, planOf(plan))
+	assert.NotZero(t, stats.Match)
+	assert.NotZero(t, stats.Apply)
+	assert.NotZero(t, stats.Distance)
+	assert.Equal(t, stats.Apply+1, stats.Clone)
+	assert.Equal(t, stats.Apply*2, stats.Rehash)
+}
+
+func TestReparent(t *testing.T) {
+	start, goal := StateOf("s", "!n", "!m", "!g"), StateOf("g")
+	actions := []Action{
+		actionOf("Teleport", 10, StateOf("s"), StateOf("!s", "m")),
+		actionOf("Walk", 1, StateOf("s"), StateOf("!s", "n")),
+		actionOf("Climb", 1, StateOf("n"), StateOf("!n", "m")),
+		actionOf("Finish", 1, StateOf("m"), StateOf("g")),
+	}
+
+	// The state reached by teleporting is reached again more cheaply by walking and climbing
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Walk", "Climb", "Finish"}, planOf(plan))
+}
+
+func TestHeuristicMemoized(t *testing.T) {
+	var stats Counters
+	_, err := Plan(StateOf("!light", "!door"), StateOf("door", "!light"), []Action{
+		actionOf("On", 1.0, StateOf("!light"), StateOf("light")),
+		actionOf("Off", 1.0, StateOf("light"), StateOf("!light")),
+		actionOf("Open", 1.0, StateOf("light"), StateOf("door")),
+	}, WithCounters(&stats))
+	assert.NoError(t, err)
+
+	// Duplicate states must not re-evaluate the heuristic
+	assert.Less(t, stats.Distance, stats.Apply+1)
+
+	// Nor the same state reached with different amounts of resources spent
+	ammo := []Resource{{Name: "ammo", Amount: 1}}
+	stats = Counters{}
+	_, err = Plan(StateOf("A"), StateOf("G"), []Action{
+		&resourceAction{testAction: testAction{name: "ShootToB", cost: 1, require: StateOf("A"), outcome: StateOf("!A", "B")}, resources: ammo},
+		actionOf("WalkToB", 5, StateOf("A"), StateOf("!A", "B")),
+		&resourceAction{testAction: testAction{name: "ShootToG", cost: 1, require: StateOf("B"), outcome: StateOf("!B", "G")}, resources: ammo},
+	}, WithResourceCap("ammo", 1), WithCounters(&stats))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), stats.Distance)
+}
+
+func TestGraphIndex(t *testing.T) {
+	h := newGraph(8, 8)
+	for i, cost := range []float32{5, 3, 8, 1, 4, 2, 7, 6} {
+		node := StateOf(fmt.Sprintf("x=%d", i+1))
+		node.totalCost = cost
+		h.Push(node)
+	}
+
+	// Every node knows its position in the heap, so it can be fixed in place
+	for i, node := range h.heap {
+		assert.Equal(t, i, node.index)
+	}
+
+	last := h.heap[h.Len()-1]
+	last.totalCost = 0
+	h.Fix(last)
+
+	var costs []float32
+	for h.Len() > 0 {
+		node, _ := h.Pop()
+		costs = append(costs, node.totalCost)
+		for i, node := range h.heap {
+			assert.Equal(t, i, node.index)
+		}
+	}
+	assert.IsNonDecreasing(t, costs)
+}
+
+func TestPlanInto(t *testing.T) {
+	start, goal := StateOf("A", "B"), StateOf("C", "D")
+	actions := []Action{move("A->C"), move("A->D"), move("B->C"), move("B->D")}
+
+	buffer := make([]Action, 0, 8)
+	plan, err := PlanInto(buffer, start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C", "B->D"}, planOf(plan))
+	assert.Equal(t, &buffer[:1][0], &plan[0])
+
+	// Reusing the same buffer should overwrite the previous plan
+	plan, err = PlanInto(plan, start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C", "B->D"}, planOf(plan))
+}
+
+func TestPlanOptions(t *testing.T) {
+	start, goal := StateOf("A", "B"), StateOf("C", "D")
+	actions := []Action{move("A->C"), move("A->D"), move("B->C"), move("B->D")}
+
+	for _, opt := range []Option{
+		WithCapacity(1024, 1024),
+		WithCapacity(0, 0),
+		WithPoolLimit(1),
+		WithoutPool(),
+	} {
+		plan, err := Plan(start, goal, actions, opt)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"A->C", "B->D"}, planOf(plan))
+	}
+
+	// A pooled graph grows its visited set to the capacity requested
+	o := optionsOf([]Option{WithCapacity(8, 4096)})
+	h := acquireHeap(&o)
+	assert.Equal(t, 4096, h.reserve)
+	h.Release()
+}
+
+func TestScratchSimulate(t *testing.T) {
+	plan, err := Plan(StateOf("!count"), StateOf("count>50"), []Action{
+		&scratchAction{name: "Count", step: 20},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Count", "Count", "Count"}, planOf(plan))
+}
+
+func TestStaticAction(t *testing.T) {
+	a := &staticAction{testAction: testAction{name: "A->B", cost: 1, require: StateOf("A"), outcome: StateOf("!A", "B")}}
+	b := &staticAction{testAction: testAction{name: "B->C", cost: 1, require: StateOf("B"), outcome: StateOf("!B", "C")}}
+
+	plan, err := Plan(StateOf("A"), StateOf("C"), []Action{a, b})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->C"}, planOf(plan))
+	assert.Equal(t, 1, a.calls)
+	assert.Equal(t, 1, b.calls)
+}
+
+func TestGoalSimulate(t *testing.T) {
+	start, goal := StateOf("x=0"), StateOf("x=42")
+	plan, err := Plan(start, goal, []Action{
+		&towardAction{fact: factOf("x")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Toward"}, planOf(plan))
+
+	// The plan is verified toward the same goal
+	assert.NoError(t, VerifyPlan(start, goal, plan))
+
+	final, err := SimulatePlan(start, plan)
+	assert.NoError(t, err)
+	assert.Equal(t, "{x=0}", final.String())
+}
+
+func TestTooManyActions(t *testing.T) {
+	_, err := Plan(StateOf("A"), StateOf("B"), make([]Action, MaxActions+1))
+	assert.ErrorIs(t, err, ErrTooManyActions)
+}
+
+func TestRecover(t *testing.T) {
+	assert.Panics(t, func() {
+		Plan(StateOf("A"), StateOf("C"), []Action{move("A->B"), &panicAction{}})
+	})
+
+	_, err := Plan(StateOf("A"), StateOf("C"), []Action{move("A->B"), &panicAction{}}, WithRecover())
+	assert.ErrorContains(t, err, "panic in action 'Faulty' on state {A=100}: oops")
+
+	var perr *PanicError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, "oops", perr.Value)
+	assert.NotEmpty(t, perr.Stack)
+}
+
+func TestOverflow(t *testing.T) {
+	start, goal := StateOf("hp=90", "!buffed"), StateOf("buffed")
+	actions := []Action{
+		actionOf("Buff", 1.0, StateOf("!buffed"), StateOf("hp+20", "buffed")),
+	}
+
+	_, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+
+	_, err = Plan(start, goal, actions, WithOverflow(OverflowError))
+	assert.ErrorIs(t, err, ErrOverflow)
+	assert.ErrorContains(t, err, "action 'Buff'")
+
+	var actionErr *ActionError
+	var factErr *FactError
+	assert.True(t, errors.As(err, &actionErr))
+	assert.True(t, errors.As(err, &factErr))
+	assert.Equal(t, "Buff", actionErr.Action)
+	assert.Equal(t, "hp", factErr.Fact)
+}
+
+func TestWaypoints(t *testing.T) {
+	actions := []Action{
+		actionOf("GetKey", 1.0, StateOf("!key"), StateOf("key")),
+		actionOf("OpenVault", 1.0, StateOf("key", "!vault"), StateOf("vault")),
+		actionOf("Escape", 1.0, StateOf("!escaped"), StateOf("escaped")),
+	}
+
+	start := StateOf("!key", "!vault", "!escaped")
+	plan, err := Plan(start, StateOf("escaped"), actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Escape"}, planOf(plan))
+
+	plan, err = Plan(start, StateOf("escaped"), actions,
+		WithWaypoints(StateOf("key"), StateOf("vault")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GetKey", "OpenVault", "Escape"}, planOf(plan))
+	assert.NoError(t, VerifyPlan(start, StateOf("key", "vault", "escaped"), plan))
+
+	// Waypoints that are already satisfied add nothing to the plan
+	plan, err = Plan(start, StateOf("escaped"), actions,
+		WithWaypoints(StateOf("!key")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Escape"}, planOf(plan))
+
+	// Unreachable waypoint
+	_, err = Plan(start, StateOf("escaped"), actions,
+		WithWaypoints(StateOf("treasure")))
+	assert.Error(t, err)
+}
+
+func TestPlanFunc(t *testing.T) {
+	resources := []fact{factOf("wood"), factOf("stone"), factOf("iron"), factOf("gold")}
+	goal := func(state *State) (bool, float32) {
+		count := 0
+		for _, f := range resources {
+			if state.load(f).Value() > 50 {
+				count++
+			}
+		}
+		return count >= 3, float32(max(3-count, 0))
+	}
+
+	plan, err := PlanFunc(StateOf("!wood", "!stone", "iron=60", "!gold"), goal, []Action{
+		actionOf("ChopWood", 1.0, StateOf(), StateOf("wood+30")),
+		actionOf("MineStone", 2.0, StateOf(), StateOf("stone+60")),
+		actionOf("MineGold", 5.0, StateOf(), StateOf("gold+60")),
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"ChopWood", "ChopWood", "MineStone"}, planOf(plan))
+
+	_, err = PlanFunc(StateOf(), func(*State) (bool, float32) { return false, 1 }, []Action{
+		move("A->B"),
+	})
+	assert.Error(t, err)
+}
+
+func TestActionFilter(t *testing.T) {
+	actions := []Action{move("A->C"), move("A->D"), move("B->C"), move("B->D")}
+	plan, err := Plan(StateOf("A", "B"), StateOf("C", "D"), actions,
+		WithActionFilter(func(action Action) bool {
+			return action.(fmt.Stringer).String() != "A->C"
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->D", "B->C"}, planOf(plan))
+
+	_, err = Plan(StateOf("A", "B"), StateOf("C", "D"), actions,
+		WithActionFilter(func(action Action) bool {
+			return !strings.HasSuffix(action.(fmt.Stringer).String(), "D")
+		}),
+	)
+	assert.ErrorContains(t, err, "facts D cannot be influenced")
+}
+
+func TestBudget(t *testing.T) {
+	start, goal := StateOf("A", "B"), StateOf("C", "D")
+	actions := []Action{move("A->C"), move("A->D", 0.5), move("B->C"), move("B->D", 0.75)}
+
+	plan, err := Plan(start, goal, actions, WithBudget(1.5))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->D", "B->C"}, planOf(plan))
+
+	_, err = Plan(start, goal, actions, WithBudget(1.25))
+	assert.ErrorIs(t, err, ErrBudget)
+}
+
+func TestInvariant(t *testing.T) {
+	start, goal := StateOf("health=50", "!treasure"), StateOf("treasure")
+	actions := []Action{
+		actionOf("Lava", 1, StateOf("!treasure"), StateOf("health-60", "treasure")),
+		actionOf("Path", 3, StateOf("!treasure"), StateOf("health-10", "treasure")),
+	}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Lava"}, planOf(plan))
+
+	plan, err = Plan(start, goal, actions, WithInvariant(StateOf("health>0")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Path"}, planOf(plan))
+
+	_, err = Plan(start, goal, actions, WithInvariant(StateOf("health>45")))
+	assert.ErrorContains(t, err, "without violating the constraints")
+}
+
+func TestForbidden(t *testing.T) {
+	start, goal := StateOf("A", "!alarm"), StateOf("C")
+	actions := []Action{
+		actionOf("Break", 1, StateOf("A"), StateOf("!A", "C", "alarm")),
+		actionOf("Sneak", 2, StateOf("A"), StateOf("!A", "B")),
+		actionOf("Open", 1, StateOf("B"), StateOf("!B", "C")),
+	}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Break"}, planOf(plan))
+
+	plan, err = Plan(start, goal, actions, WithForbidden(StateOf("alarm=100")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Sneak", "Open"}, planOf(plan))
+
+	_, err = Plan(start, goal, actions, WithForbidden(StateOf("alarm"), StateOf("B")))
+	assert.ErrorContains(t, err, "without violating the constraints")
+}
+
+func TestPrevious(t *testing.T) {
+	start, goal := StateOf("A", "B"), StateOf("C", "D")
+	actions := []Action{move("A->C"), move("A->D"), move("B->C"), move("B->D")}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C", "B->D"}, planOf(plan))
+
+	// An equally good alternative is kept if it was the previous plan
+	previous := []Action{actions[1], actions[2]}
+	plan, err = Plan(start, goal, actions, WithPrevious(previous, 0.1))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->D", "B->C"}, planOf(plan))
+
+	// Once the previous plan is no longer valid, it is replaced
+	plan, err = Plan(StateOf("A", "!B", "D"), goal, actions, WithPrevious(previous, 0.1))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C"}, planOf(plan))
+}
+
+func TestMultiCost(t *testing.T) {
+	start, goal := StateOf("A"), StateOf("C")
+	run := &multiCostAction{testAction: *move("A->C").(*testAction), costs: []float32{1, 5}}
+	sneak := &multiCostAction{testAction: *move("A->C").(*testAction), costs: []float32{3, 0}}
+	walk := &multiCostAction{testAction: *move("A->C").(*testAction), costs: []float32{1, 2}}
+	run.name, sneak.name, walk.name = "Run", "Sneak", "Walk"
+
+	plan, err := Plan(start, goal, []Action{sneak, run}, WithCostWeights(1, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Run"}, planOf(plan))
+
+	plan, err = Plan(start, goal, []Action{run, sneak}, WithCostWeights(1, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Sneak"}, planOf(plan))
+
+	plan, err = Plan(start, goal, []Action{run, sneak, walk}, WithLexicographic())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Walk"}, planOf(plan))
+
+	run.costs = []float32{1, 2, 3, 4, 5}
+	_, err = Plan(start, goal, []Action{run}, WithLexicographic())
+	assert.ErrorIs(t, err, ErrTooManyCosts)
+}
+
+func TestMinSuccess(t *testing.T) {
+	start, goal := StateOf("A"), StateOf("C")
+	jump := &riskyAction{testAction: *move("A->C").(*testAction), risk: 0.3}
+	actions := []Action{jump, move("A->B"), move("B->C")}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C"}, planOf(plan))
+	assert.InDelta(t, 0.7, SuccessOf(plan), 1e-6)
+
+	plan, err = Plan(start, goal, actions, WithMinSuccess(0.8))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->C"}, planOf(plan))
+	assert.Equal(t, float32(1), SuccessOf(plan))
+
+	_, err = Plan(start, goal, actions[:1], WithMinSuccess(0.8))
+	assert.ErrorContains(t, err, "without violating the constraints")
+}
+
+func TestMinSuccessPath(t *testing.T) {
+	actions := []Action{
+		&riskyAction{testAction: testAction{name: "JumpToB", cost: 1, require: StateOf("A"), outcome: StateOf("!A", "B")}, risk: 0.15},
+		actionOf("WalkToB", 5, StateOf("A"), StateOf("!A", "B")),
+		&riskyAction{testAction: testAction{name: "JumpToG", cost: 1, require: StateOf("B"), outcome: StateOf("!B", "G")}, risk: 0.15},
+	}
+
+	// Reaching B by jumping must not hide the safer path reaching it by walking
+	plan, err := Plan(StateOf("A"), StateOf("G"), actions, WithMinSuccess(0.8))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"WalkToB", "JumpToG"}, planOf(plan))
+	assert.InDelta(t, 0.85, SuccessOf(plan), 1e-6)
+}
+
+func TestSeed(t *testing.T) {
+	start, goal := StateOf("A", "B"), StateOf("C", "D")
+	actions := []Action{move("A->C"), move("A->D"), move("B->C"), move("B->D")}
+
+	plans := make(map[string]int)
+	for seed := uint64(1); seed <= 32; seed++ {
+		plan, err := Plan(start, goal, actions, WithSeed(seed))
+		assert.NoError(t, err)
+		plans[strings.Join(planOf(plan), ",")]++
+
+		// The same seed always produces the same plan
+		again, err := Plan(start, goal, actions, WithSeed(seed))
+		assert.NoError(t, err)
+		assert.Equal(t, planOf(plan), planOf(again))
+	}
+
+	assert.Greater(t, len(plans), 1)
+}
+
+func TestHashSeed(t *testing.T) {
+	start, goal := StateOf("A", "!B", "!C", "!D"), StateOf("D")
+	actions := []Action{move("A->B"), move("B->C"), move("C->D"), move("A->C", 3)}
+
+	expect, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	for seed := uint64(1); seed <= 8; seed++ {
+		plan, err := Plan(start, goal, actions, WithHashSeed(seed), WithoutPool())
+		assert.NoError(t, err)
+		assert.Equal(t, planOf(expect), planOf(plan))
+	}
+
+	// The seeded hash of an overlay is the one of its flattened state
+	overlay := StateOf("C").WithBase(StateOf("A", "B"))
+	assert.Equal(t, StateOf("A", "B", "C").seededHash(42), overlay.seededHash(42))
+	assert.NotEqual(t, overlay.seededHash(42), overlay.seededHash(43))
+}
+
+func TestOpenLimit(t *testing.T) {
+	start, goal := StateOf(), StateOf()
+	var actions []Action
+	for _, name := range []string{"A", "B", "C", "D", "E", "F"} {
+		start.Add("!" + name)
+		goal.Add(name)
+		actions = append(actions, actionOf("set "+name, 1, StateOf("!"+name), StateOf(name)))
+	}
+
+	for _, strategy := range []Pruning{PruneWorst, PruneOldest} {
+		plan, err := Plan(start, goal, actions, WithOpenLimit(4, strategy))
+		assert.NoError(t, err)
+		assert.Len(t, plan, 6)
+		assert.NoError(t, VerifyPlan(start, goal, plan))
+	}
+
+	// Removing from the middle of the heap keeps it ordered
+	heap := newGraph(8, 8)
+	for i, cost := range []float32{5, 1, 4, 2, 3} {
+		node := StateOf()
+		node.totalCost = cost
+		node.key = uint32(i)
+		heap.Push(node)
+	}
+
+	assert.Equal(t, float32(5), heap.Remove(heap.victim(PruneWorst)).totalCost)
+	assert.Equal(t, float32(1), heap.Remove(heap.victim(PruneOldest)).totalCost)
+	for _, expect := range []float32{2, 3, 4} {
+		node, _ := heap.Pop()
+		assert.Equal(t, expect, node.totalCost)
+	}
+}
+
+func TestStance(t *testing.T) {
+	start, goal := StateOf("!food"), StateOf("food>25")
+	actions := []Action{
+		actionOf("forage", 1, StateOf(), StateOf("food+10..30")),
+	}
+
+	for stance, expect := range map[Stance]int{
+		StanceOptimistic:  1,
+		StanceExpected:    2,
+		StancePessimistic: 3,
+	} {
+		plan, err := Plan(start, goal, actions, WithStance(stance))
+		assert.NoError(t, err)
+		assert.Len(t, plan, expect)
+	}
+
+	// A pessimistic stance assumes the largest decrease
+	start, goal = StateOf("stamina=50", "!ran"), StateOf("top")
+	actions = []Action{
+		actionOf("run", 1, StateOf("!ran"), StateOf("ran", "stamina-10..30")),
+		actionOf("walk", 5, StateOf("!ran"), StateOf("ran")),
+		actionOf("climb", 1, StateOf("ran", "stamina>30"), StateOf("top")),
+	}
+
+	for stance, expect := range map[Stance][]string{
+		StanceOptimistic:  {"run", "climb"},
+		StanceExpected:    {"walk", "climb"},
+		StancePessimistic: {"walk", "climb"},
+	} {
+		plan, err := Plan(start, goal, actions, WithStance(stance))
+		assert.NoError(t, err)
+		assert.Equal(t, expect, planOf(plan))
+	}
+}
+
+func TestTimeFact(t *testing.T) {
+	start, goal := StateOf("!time", "home", "!food"), StateOf("food", "time<80")
+	actions := []Action{
+		&timedAction{testAction: *actionOf("Walk", 1, StateOf("home"), StateOf("!home", "town")).(*testAction), duration: 30},
+		&timedAction{testAction: *actionOf("Shop", 1, StateOf("town", "time<60"), StateOf("food")).(*testAction), duration: 10},
+		&timedAction{testAction: *actionOf("Hunt", 300, StateOf("home"), StateOf("food")).(*testAction), duration: 70},
+	}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Walk", "Shop"}, planOf(plan))
+	assert.NoError(t, VerifyPlan(start, goal, plan))
+
+	// The shop is closed by the time we get to town
+	plan, err = Plan(StateOf("time=40", "home", "!food"), StateOf("food"), actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Hunt"}, planOf(plan))
+
+	// Nor can we hunt in time
+	_, err = Plan(StateOf("time=40", "home", "!food"), goal, actions)
+	assert.Error(t, err)
+}
+
+func TestJitter(t *testing.T) {
+	start, goal := StateOf("A", "B"), StateOf("C", "D")
+	actions := []Action{move("A->C"), move("A->D"), move("B->C"), move("B->D"), move("A->E", 1.5)}
+
+	plans := make(map[string]int)
+	for seed := uint64(1); seed <= 32; seed++ {
+		plan, err := Plan(start, goal, actions, WithSeed(seed), WithJitter(0.1))
+		assert.NoError(t, err)
+		assert.Len(t, plan, 2)
+		plans[strings.Join(planOf(plan), ",")]++
+
+		// The same seed always produces the same plan
+		again, err := Plan(start, goal, actions, WithSeed(seed), WithJitter(0.1))
+		assert.NoError(t, err)
+		assert.Equal(t, planOf(plan), planOf(again))
+	}
+
+	assert.Greater(t, len(plans), 1)
+}
+
+func TestWindow(t *testing.T) {
+	start, goal := StateOf("!food", "!time"), StateOf("food")
+	actions := []Action{
+		&windowedAction{testAction: *actionOf("Shop", 1, StateOf(), StateOf("food")).(*testAction), window: StateOf("time<60")},
+		actionOf("Hunt", 5, StateOf(), StateOf("food")),
+	}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Shop"}, planOf(plan))
+	assert.NoError(t, VerifyPlan(start, goal, plan))
+
+	// The shop is closed in the evening
+	evening := StateOf("!food", "time=80")
+	plan, err = Plan(evening, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Hunt"}, planOf(plan))
+	assert.Error(t, VerifyPlan(evening, goal, actions[:1]))
+}
+
+func TestPlanner(t *testing.T) {
+	planner, err := NewPlanner([]Action{
+		move("A->C"), move("A->D", 0.5), move("B->C"), move("B->D", 0.75),
+	}, WithBudget(2))
+	assert.NoError(t, err)
+	assert.Len(t, planner.Actions(), 4)
+
+	plan, err := planner.Plan(StateOf("A", "B"), StateOf("C", "D"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->D", "B->C"}, planOf(plan))
+
+	plan, err = planner.PlanInto(plan, StateOf("A", "B"), StateOf("C", "D"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->D", "B->C"}, planOf(plan))
+
+	// Per-call options are applied on top of the planner ones
+	_, err = planner.Plan(StateOf("A", "B"), StateOf("C", "D"), WithBudget(1))
+	assert.ErrorIs(t, err, ErrBudget)
+
+	plan, err = planner.PlanFunc(StateOf("A"), func(s *State) (bool, float32) {
+		ok, _ := s.Match(StateOf("D"))
+		return ok, 0
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->D"}, planOf(plan))
+
+	_, err = NewPlanner(make([]Action, MaxActions+1))
+	assert.ErrorIs(t, err, ErrTooManyActions)
+}
+
+func TestPools(t *testing.T) {
+	retained, state := newGraph(1, 1), newState(1)
+	ruleCache.Store("A", ruleOf(factOf("A"), exprOf(opEqual, 42)))
+	graphs.Put(retained)
+	pool.Put(state)
+
+	// Neither the graph nor the state retained before the reset is reused
+	ResetPools()
+	WarmPools(1, 8)
+	for i := 0; i < 16; i++ {
+		assert.NotSame(t, retained, graphs.Get().(*graph))
+		assert.NotSame(t, state, pool.Get().(*State))
+	}
+
+	_, ok := ruleCache.Load("A")
+	assert.False(t, ok)
+}
+
 // ------------------------------------ Test Action ------------------------------------
 
+func TestFinal(t *testing.T) {
+	start := StateOf("hunger=80", "!food", "!tired")
+	actions := []Action{
+		actionOf("Eat", 1.0, StateOf("food>0"), StateOf("hunger-50", "food-5")),
+		actionOf("Forage", 1.0, StateOf("tired<50"), StateOf("tired+20", "food+10", "hunger+5")),
+		actionOf("Sleep", 1.0, StateOf("tired>30"), StateOf("tired-50")),
+	}
+
+	final := StateOf()
+	plan, err := Plan(start, StateOf("food>80"), actions, WithFinal(final))
+	assert.NoError(t, err)
+
+	// The final state is the one obtained by applying every outcome of the plan
+	expect := start.Clone()
+	for _, action := range plan {
+		_, outcome := action.Simulate(expect)
+		assert.NoError(t, expect.Apply(outcome))
+	}
+
+	assert.Equal(t, expect.String(), final.String())
+	assert.Equal(t, expect.Hash(), final.Hash())
+	assert.Equal(t, "{food=90, tired=30, hunger=100}", final.String())
+}
+
 func move(m string, w ...float32) Action {
 	if len(w) == 0 {
 		w = append(w, 1.0)
@@ -188,3 +846,171 @@ func (a *testAction) Cost() float32 {
 func (a *testAction) String() string {
 	return a.name
 }
+
+type staticAction struct {
+	testAction
+	calls int
+}
+
+func (a *staticAction) Simulate(current *State) (*State, *State) {
+	a.calls++
+	return a.testAction.Simulate(current)
+}
+
+func (a *staticAction) Static() bool {
+	return true
+}
+
+type towardAction struct {
+	fact fact
+}
+
+func (a *towardAction) Simulate(_ *State) (*State, *State) {
+	panic("not supported")
+}
+
+func (a *towardAction) SimulateGoal(current *State, goal View) (*State, *State) {
+	for _, r := range goal.Rules() {
+		if r.Fact() == a.fact {
+			return StateOf(), StateOfRules(r)
+		}
+	}
+	return StateOf(), StateOf()
+}
+
+func (a *towardAction) Cost() float32 {
+	return 1
+}
+
+func (a *towardAction) String() string {
+	return "Toward"
+}
+
+type multiCostAction struct {
+	testAction
+	costs []float32
+}
+
+func (a *multiCostAction) Costs() []float32 {
+	return a.costs
+}
+
+type riskyAction struct {
+	testAction
+	risk float32
+}
+
+func (a *riskyAction) Risk() float32 {
+	return a.risk
+}
+
+type timedAction struct {
+	testAction
+	duration float32
+}
+
+func (a *timedAction) Duration() float32 {
+	return a.duration
+}
+
+type windowedAction struct {
+	testAction
+	window *State
+}
+
+func (a *windowedAction) Window() *State {
+	return a.window
+}
+
+type scratchAction struct {
+	name string
+	step float32
+}
+
+func (a *scratchAction) Simulate(_ *State) (*State, *State) {
+	panic("not supported")
+}
+
+func (a *scratchAction) SimulateInto(current, require, outcome *State) {
+	count := current.load(factOf("count"))
+	require.AddRule(ruleOf(factOf("count"), count))
+	outcome.AddRule(ruleOf(factOf("count"), exprOf(opIncrement, a.step)))
+}
+
+func (a *scratchAction) Cost() float32 {
+	return 1
+}
+
+func (a *scratchAction) String() string {
+	return a.name
+}
+
+type panicAction struct{}
+
+func (a *panicAction) Simulate(_ *State) (*State, *State) {
+	panic("oops")
+}
+
+func (a *panicAction) Cost() float32 {
+	return 1
+}
+
+func (a *panicAction) String() string {
+	return "Faulty"
+}
+
+func TestTrace(t *testing.T) {
+	var buffer bytes.Buffer
+	plan, err := Plan(StateOf("A"), StateOf("C"), []Action{move("A->B"), move("B->C")}, WithTrace(&buffer))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->C"}, planOf(plan))
+
+	var trace struct {
+		Events []struct {
+			Name  string `json:"name"`
+			Cat   string `json:"cat"`
+			Phase string `json:"ph"`
+		} `json:"traceEvents"`
+	}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &trace))
+
+	count := make(map[string]int)
+	for _, e := range trace.Events {
+		count[e.Cat+"/"+e.Name]++
+	}
+
+	assert.Equal(t, 2, count["search/expand"])
+	assert.Equal(t, 1, count["search/plan"])
+	assert.Equal(t, 3, count["heap/push"])
+	assert.Equal(t, 3, count["heap/pop"])
+	assert.Equal(t, 2, count["simulate/A->B"])
+}
+
+func TestBreakpoint(t *testing.T) {
+	actions := []Action{move("A->B"), move("B->C"), move("A->D", 5), move("D->C")}
+
+	// Pause the first time the action is applied and look at the path
+	var hits []Hit
+	plan, err := Plan(StateOf("A"), StateOf("C"), actions, WithBreakpoint(Breakpoint{Action: "D->C"}, func(hit Hit) bool {
+		hits = append(hits, hit)
+		return true
+	}), WithBreakpoint(Breakpoint{State: StateOf("B")}, func(hit Hit) bool {
+		hits = append(hits, hit)
+		return true
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->C"}, planOf(plan))
+	assert.Len(t, hits, 1)
+	assert.Equal(t, []string{"A->B"}, planOf(hits[0].Path))
+	assert.Equal(t, float32(1), hits[0].Cost)
+
+	// The breakpoint can stop the search
+	_, err = Plan(StateOf("A"), StateOf("C"), actions, WithBreakpoint(Breakpoint{
+		Action: "A->D",
+		State:  StateOf("D"),
+	}, func(hit Hit) bool {
+		assert.Equal(t, "A->D", nameOf(hit.Action))
+		return false
+	}))
+	assert.ErrorIs(t, err, ErrStopped)
+}
@@ -0,0 +1,48 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// MovingAction can be optionally implemented by the actions moving the agent between two
+// places, such as "A->B", so the planner can ask a CostProvider for the cost of the move.
+type MovingAction interface {
+
+	// Move returns the facts of the place the agent moves from and the place it moves to.
+	Move() (from, to string)
+}
+
+// CostProvider represents an external system, such as a navmesh pathfinder or an influence
+// map, which knows the actual cost of moving between two places better than the cost
+// declared by the action.
+type CostProvider interface {
+
+	// MoveCost returns the cost of moving from a place to another. A negative, infinite
+	// or NaN cost means the provider does not know, and the declared cost is used.
+	MoveCost(from, to string) float32
+}
+
+// WithCostProvider consults the external provider for the cost of the actions implementing
+// MovingAction, keeping the movement costs of the plan realistic. The provider is queried
+// for every move in every state expanded, so it should cache its answers if they are slow
+// to compute. A learned cost model set with WithCostModel takes precedence.
+func WithCostProvider(provider CostProvider) Option {
+	return func(o *options) {
+		o.provider = provider
+	}
+}
+
+// movementCost returns the cost of the move queried from the provider, and false if the
+// action does not move or the provider has no usable estimate.
+func movementCost(provider CostProvider, action Action) (float32, bool) {
+	if provider == nil {
+		return 0, false
+	}
+
+	if mover, ok := action.(MovingAction); ok {
+		from, to := mover.Move()
+		if cost := provider.MoveCost(from, to); estimated(cost) {
+			return cost, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCostProvider(t *testing.T) {
+	start, goal := StateOf("A"), StateOf("D")
+	actions := []Action{
+		movingOf("A->B"), movingOf("B->D"),
+		movingOf("A->C"), movingOf("C->D"),
+	}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->D"}, planOf(plan))
+
+	// The navmesh knows the bridge between A and B is long
+	navmesh := navmesh{"A->B": 10, "C->D": float32(math.NaN())}
+	plan, err = Plan(start, goal, actions, WithCostProvider(navmesh))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->C", "C->D"}, planOf(plan))
+}
+
+type navmesh map[string]float32
+
+func (n navmesh) MoveCost(from, to string) float32 {
+	if cost, ok := n[from+"->"+to]; ok {
+		return cost
+	}
+	return -1
+}
+
+type movingAction struct {
+	testAction
+}
+
+func movingOf(m string) Action {
+	return &movingAction{testAction: *move(m).(*testAction)}
+}
+
+func (a *movingAction) Move() (from, to string) {
+	from, to, _ = strings.Cut(a.name, "->")
+	return
+}
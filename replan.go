@@ -0,0 +1,87 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// ReplanPolicy represents the hysteresis of the replanning of an agent, preventing it from
+// thrashing when noisy sensors flip facts every frame.
+type ReplanPolicy struct {
+	MinTicks  int     // Minimum number of ticks between two replans
+	MinChange float32 // Minimum change of the world since the last replan, summed over the facts
+}
+
+// Replanner decides on every tick whether an agent should replan, given how much the world
+// changed since its last plan. A replanner keeps the state of a single agent and is not
+// safe for concurrent use.
+type Replanner struct {
+	policy   ReplanPolicy // The hysteresis of the replanning
+	ticks    int          // The number of ticks since the last replan
+	baseline *State       // The state of the world at the last replan, nil if none
+}
+
+// NewReplanner creates a new replanner with the hysteresis policy.
+func NewReplanner(policy ReplanPolicy) *Replanner {
+	return &Replanner{
+		policy: policy,
+	}
+}
+
+// Tick is called once per tick with the current state of the world and returns whether the
+// agent should replan now. It does so when the world changed by at least the minimum since
+// the last replan and at least the minimum number of ticks elapsed, or immediately if the
+// plan failed or there was no plan yet.
+func (r *Replanner) Tick(current *State, failed bool) bool {
+	r.ticks++
+	switch {
+	case failed || r.baseline == nil:
+	case r.ticks < r.policy.MinTicks:
+		return false
+	case changeOf(r.baseline, current) < max(r.policy.MinChange, 0):
+		return false
+	case r.policy.MinChange <= 0 && r.baseline.Equals(current):
+		return false
+	}
+
+	r.Reset()
+	r.baseline = current.Flatten()
+	return true
+}
+
+// Reset forgets the last replan, so the agent replans on the next tick.
+func (r *Replanner) Reset() {
+	if r.baseline != nil {
+		r.baseline.release()
+	}
+	r.baseline = nil
+	r.ticks = 0
+}
+
+// changeOf returns the magnitude of the change between the two states, as the sum of the
+// absolute differences of the values of their facts, missing facts being zero.
+func changeOf(before, after *State) (change float32) {
+	flat := after.Flatten()
+	defer flat.release()
+
+	for _, r := range flat.vx {
+		v := float32(0)
+		if e, ok := before.lookup(r.Fact()); ok {
+			v = e.Value()
+		}
+		change += abs(r.Expr().Value() - v)
+	}
+
+	for _, r := range before.vx {
+		if _, ok := flat.lookup(r.Fact()); !ok {
+			change += abs(r.Expr().Value())
+		}
+	}
+	return change
+}
+
+// abs returns the absolute value.
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
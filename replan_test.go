@@ -0,0 +1,44 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplanner(t *testing.T) {
+	r := NewReplanner(ReplanPolicy{MinTicks: 2, MinChange: 10})
+
+	// The first tick always plans
+	assert.True(t, r.Tick(StateOf("hunger=50", "enemy"), false))
+
+	// Noisy sensors changing the world slightly are ignored
+	assert.False(t, r.Tick(StateOf("hunger=52", "enemy"), false))
+	assert.False(t, r.Tick(StateOf("hunger=48", "enemy"), false))
+
+	// Significant changes are throttled by the minimum number of ticks
+	assert.True(t, r.Tick(StateOf("hunger=50", "!enemy"), false))
+	assert.False(t, r.Tick(StateOf("hunger=50", "enemy"), false))
+	assert.True(t, r.Tick(StateOf("hunger=50", "enemy"), false))
+
+	// Failures always replan
+	assert.True(t, r.Tick(StateOf("hunger=50", "enemy"), true))
+
+	// Facts appearing or vanishing count as changes
+	r.Reset()
+	assert.True(t, r.Tick(StateOf("hunger=50"), false))
+	assert.False(t, r.Tick(StateOf("hunger=50", "noise=5"), false))
+	assert.True(t, r.Tick(StateOf("hunger=50", "noise=20"), false))
+	assert.False(t, r.Tick(StateOf(), false))
+	assert.True(t, r.Tick(StateOf(), false))
+}
+
+func TestReplannerAnyChange(t *testing.T) {
+	r := NewReplanner(ReplanPolicy{})
+	assert.True(t, r.Tick(StateOf("A"), false))
+	assert.False(t, r.Tick(StateOf("A"), false))
+	assert.True(t, r.Tick(StateOf("A", "B"), false))
+}
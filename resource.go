@@ -0,0 +1,62 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// Resource represents an amount of a named resource, such as ammo or gold, which is
+// accounted for separately from the facts of the world.
+type Resource struct {
+	Name   string  // The name of the resource
+	Amount float32 // The amount consumed, or produced if negative
+}
+
+// ResourceAction can be optionally implemented by actions which consume or produce
+// resources, separating what the world looks like from what the plan spends.
+type ResourceAction interface {
+
+	// Resources returns the resources consumed (positive) or produced (negative)
+	// by performing the action.
+	Resources() []Resource
+}
+
+// ResourcesOf returns the net amount of every resource consumed by the plan, the
+// amounts being negative for the resources the plan produces.
+func ResourcesOf(plan []Action) map[string]float32 {
+	totals := make(map[string]float32, 4)
+	for _, action := range plan {
+		if r, ok := action.(ResourceAction); ok {
+			for _, resource := range r.Resources() {
+				totals[resource.Name] += resource.Amount
+			}
+		}
+	}
+	return totals
+}
+
+// spent returns the net amount of the resource consumed along the path to the node.
+func spent(node *State, name string) (total float32) {
+	for n := node; n != nil; n = n.parent {
+		total += consumed(n.action, name)
+	}
+	return total
+}
+
+// spentBy returns the net amount of the resource consumed by the plan.
+func spentBy(plan []Action, name string) (total float32) {
+	for _, action := range plan {
+		total += consumed(action, name)
+	}
+	return total
+}
+
+// consumed returns the amount of the resource consumed by the action.
+func consumed(action Action, name string) (total float32) {
+	if r, ok := action.(ResourceAction); ok {
+		for _, resource := range r.Resources() {
+			if resource.Name == name {
+				total += resource.Amount
+			}
+		}
+	}
+	return total
+}
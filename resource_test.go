@@ -0,0 +1,57 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceCap(t *testing.T) {
+	start, goal := StateOf("enemy=100"), StateOf("!enemy")
+	actions := []Action{
+		&resourceAction{
+			testAction: testAction{name: "Shoot", cost: 1, require: StateOf("enemy>0"), outcome: StateOf("enemy-50")},
+			resources:  []Resource{{Name: "ammo", Amount: 1}},
+		},
+		actionOf("Stab", 300, StateOf("enemy>0"), StateOf("!enemy")),
+	}
+
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Shoot", "Shoot"}, planOf(plan))
+	assert.Equal(t, map[string]float32{"ammo": 2}, ResourcesOf(plan))
+
+	plan, err = Plan(start, goal, actions, WithResourceCap("ammo", 1))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Stab"}, planOf(plan))
+	assert.Empty(t, ResourcesOf(plan))
+
+	_, err = Plan(start, goal, actions[:1], WithResourceCap("ammo", 1))
+	assert.ErrorContains(t, err, "without violating the constraints")
+}
+
+func TestResourceCapPath(t *testing.T) {
+	ammo := []Resource{{Name: "ammo", Amount: 1}}
+	actions := []Action{
+		&resourceAction{testAction: testAction{name: "ShootToB", cost: 1, require: StateOf("A"), outcome: StateOf("!A", "B")}, resources: ammo},
+		actionOf("WalkToB", 5, StateOf("A"), StateOf("!A", "B")),
+		&resourceAction{testAction: testAction{name: "ShootToG", cost: 1, require: StateOf("B"), outcome: StateOf("!B", "G")}, resources: ammo},
+	}
+
+	// Reaching B by shooting must not hide the path reaching it by walking
+	plan, err := Plan(StateOf("A"), StateOf("G"), actions, WithResourceCap("ammo", 1))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"WalkToB", "ShootToG"}, planOf(plan))
+}
+
+type resourceAction struct {
+	testAction
+	resources []Resource
+}
+
+func (a *resourceAction) Resources() []Resource {
+	return a.resources
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// RiskyAction can be optionally implemented by actions which may fail when performed,
+// so that the planner can bound the cumulative failure risk of the plan.
+type RiskyAction interface {
+
+	// Risk returns the probability of the action failing, between 0 and 1.
+	Risk() float32
+}
+
+// SuccessProbability returns the probability of the action succeeding, 1 for actions
+// which are not risky. Both the planner and the executor rely on it.
+func SuccessProbability(action Action) float32 {
+	return 1 - riskOf(action)
+}
+
+// SuccessOf returns the probability of every action of the plan succeeding, assuming
+// the failures of the actions are independent.
+func SuccessOf(plan []Action) float32 {
+	success := float32(1)
+	for _, action := range plan {
+		success *= 1 - riskOf(action)
+	}
+	return success
+}
+
+// successOf returns the probability of reaching the node without any failure.
+func successOf(node *State) float32 {
+	success := float32(1)
+	for n := node; n != nil; n = n.parent {
+		success *= 1 - riskOf(n.action)
+	}
+	return success
+}
+
+// riskOf returns the probability of the action failing.
+func riskOf(action Action) float32 {
+	if r, ok := action.(RiskyAction); ok {
+		return min(max(r.Risk(), 0), 1)
+	}
+	return 0
+}
@@ -0,0 +1,179 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	defaultCandidates = 64 // Default number of candidate sequences of the rollouts
+	defaultSamples    = 32 // Default number of rollouts of every candidate
+)
+
+var errNoRollout = fmt.Errorf("%w within the rollouts", ErrNoPlan)
+
+// Rollouts represents the configuration of the Monte Carlo planning.
+type Rollouts struct {
+	Candidates int    // The number of candidate sequences to generate, 64 if zero
+	Samples    int    // The number of rollouts of every candidate, 32 if zero
+	Seed       uint64 // The seed of the rollouts, the same seed producing the same plan
+}
+
+// candidate represents a sequence of actions reaching the goal, along with the total cost
+// and the distance to the goal before each of its actions.
+type candidate struct {
+	plan     []Action  // The sequence of actions
+	cost     []float32 // The total cost after each action
+	distance []float32 // The distance to the goal before each action
+}
+
+// PlanRollout finds a plan for stochastic domains, where risky actions may fail, using seeded
+// Monte Carlo rollouts rather than a deterministic A* search. It generates candidate action
+// sequences reaching the goal by random walks, then performs each of them repeatedly with
+// the actions failing according to their risk. A failure abandons the rollout, which is
+// charged the cost spent plus the remaining distance to the goal. A candidate scores the
+// total charged over its rollouts per successful one, the expected cost of reaching the
+// goal when starting over after every failure, so a cheap but fragile plan loses to a
+// robust one unless it is cheap enough to be worth retrying.
+func PlanRollout(start, goal *State, actions []Action, config Rollouts) ([]Action, error) {
+	if len(actions) > MaxActions {
+		return nil, fmt.Errorf("%w, %d actions provided", ErrTooManyActions, len(actions))
+	}
+
+	candidates := max(config.Candidates, 0)
+	if candidates == 0 {
+		candidates = defaultCandidates
+	}
+
+	samples := max(config.Samples, 0)
+	if samples == 0 {
+		samples = defaultSamples
+	}
+
+	rng := random(config.Seed)
+	var best candidate
+	var found bool
+	var bestScore float32
+	for i := 0; i < candidates; i++ {
+		c, ok, err := walk(start, goal, actions, &rng)
+		switch {
+		case err != nil:
+			return nil, err
+		case !ok:
+			continue
+		}
+
+		score := c.score(samples, &rng)
+		if !found || score < bestScore || (score == bestScore && len(c.plan) < len(best.plan)) {
+			best, bestScore, found = c, score, true
+		}
+	}
+
+	if !found {
+		return nil, errNoRollout
+	}
+	return best.plan, nil
+}
+
+// walk generates a candidate sequence by performing random applicable actions from the start
+// state, assuming every action succeeds, and returns whether it reached the goal.
+func walk(start, goal *State, actions []Action, rng *random) (candidate, bool, error) {
+	current := start.Clone()
+	require, outcome := StateOf(), StateOf()
+	defer current.release()
+	defer require.release()
+	defer outcome.release()
+
+	var c candidate
+	var total float32
+	applicable := make([]Action, 0, len(actions))
+	for depth := 0; depth < maxDepth; depth++ {
+		if done, err := current.Match(goal); err != nil || done {
+			return c, done, err
+		}
+
+		applicable = applicable[:0]
+		for _, action := range actions {
+			r, _ := simulate(action, current, goal, require, outcome)
+			match, err := current.Match(r)
+			if err == nil && match {
+				match, err = available(current, action)
+			}
+
+			switch {
+			case err != nil:
+				return c, false, err
+			case match:
+				applicable = append(applicable, action)
+			}
+		}
+
+		if len(applicable) == 0 {
+			return c, false, nil
+		}
+
+		action := applicable[rng.next()%uint64(len(applicable))]
+		c.distance = append(c.distance, current.Distance(goal))
+		if err := simulateStep(current, goal, depth, action, require, outcome, OverflowSaturate, StanceExpected); err != nil {
+			return c, false, err
+		}
+
+		total += action.Cost()
+		c.plan = append(c.plan, action)
+		c.cost = append(c.cost, total)
+	}
+
+	return c, false, nil
+}
+
+// score returns the total charged over the rollouts of the candidate per successful one,
+// lower being better, or infinity if every rollout failed.
+func (c *candidate) score(samples int, rng *random) float32 {
+	var sum float32
+	var succeeded int
+	for i := 0; i < samples; i++ {
+		charge, ok := c.rollout(rng)
+		if ok {
+			succeeded++
+		}
+		sum += charge
+	}
+
+	if succeeded == 0 {
+		return float32(math.Inf(1))
+	}
+	return sum / float32(succeeded)
+}
+
+// rollout performs the candidate once with the actions failing according to their risk, and
+// returns the cost charged and whether it succeeded. A failed rollout is charged the cost
+// spent, including the failed action, plus the remaining distance to the goal.
+func (c *candidate) rollout(rng *random) (float32, bool) {
+	for i, action := range c.plan {
+		if risk := riskOf(action); risk > 0 && rng.float() < risk {
+			return c.cost[i] + c.distance[i], false
+		}
+	}
+
+	if len(c.cost) == 0 {
+		return 0, true
+	}
+	return c.cost[len(c.cost)-1], true
+}
+
+// random represents a seeded pseudo-random generator, based on the splitmix64 sequence.
+type random uint64
+
+// next returns the next pseudo-random number of the sequence.
+func (r *random) next() uint64 {
+	*r += 0x9e3779b97f4a7c15
+	return mix(uint64(*r))
+}
+
+// float returns the next pseudo-random number of the sequence, between 0 and 1.
+func (r *random) float() float32 {
+	return float32(r.next()>>40) / (1 << 24)
+}
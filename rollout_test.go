@@ -0,0 +1,64 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanRollout(t *testing.T) {
+	start, goal := StateOf("A", "!B", "!D"), StateOf("D")
+	actions := []Action{
+		move("A->B", 2), move("B->D", 2),
+		&riskyAction{testAction: *actionOf("A->D", 1, StateOf("A"), StateOf("!A", "D")).(*testAction), risk: 0.6},
+	}
+
+	// The deterministic search ignores the risk of the shortcut
+	plan, err := Plan(start, goal, actions)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->D"}, planOf(plan))
+
+	// The rollouts find out that the shortcut fails most of the time
+	plan, err = PlanRollout(start, goal, actions, Rollouts{Seed: 42})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->D"}, planOf(plan))
+
+	// The same seed produces the same plan
+	again, err := PlanRollout(start, goal, actions, Rollouts{Seed: 42})
+	assert.NoError(t, err)
+	assert.Equal(t, plan, again)
+}
+
+func TestPlanRolloutRisky(t *testing.T) {
+	actions := []Action{
+		actionOf("safe", 10, StateOf(), StateOf("x=2")),
+		&riskyAction{testAction: *actionOf("risky", 1, StateOf(), StateOf("x=2")).(*testAction), risk: 0.95},
+	}
+
+	// Failing is never cheaper than finishing the plan
+	plan, err := PlanRollout(StateOf("x=0"), StateOf("x>1"), actions, Rollouts{Seed: 42})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"safe"}, planOf(plan))
+
+	// A risk low enough is worth retrying
+	actions[1].(*riskyAction).risk = 0.1
+	plan, err = PlanRollout(StateOf("x=0"), StateOf("x>1"), actions, Rollouts{Seed: 42})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"risky"}, planOf(plan))
+}
+
+func TestPlanRolloutNoPlan(t *testing.T) {
+	_, err := PlanRollout(StateOf("A", "!B"), StateOf("C"), []Action{
+		move("A->B"),
+	}, Rollouts{Candidates: 4, Samples: 1})
+	assert.Error(t, err)
+
+	plan, err := PlanRollout(StateOf("A"), StateOf("A"), []Action{
+		move("A->B"),
+	}, Rollouts{})
+	assert.NoError(t, err)
+	assert.Empty(t, plan)
+}
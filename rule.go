@@ -2,40 +2,326 @@ package goap
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/zeebo/xxh3"
 )
 
-var factCache = new(sync.Map)
+const (
+	defaultCacheSize     = 1 << 16 // Default maximum number of fact names kept in the cache
+	defaultRuleCacheSize = 1 << 12 // Default maximum number of parsed rules kept in the cache
+)
+
+var factCache = newNameCache(defaultCacheSize)
+var ruleCache = newParseCache(defaultRuleCacheSize)
+
+// caseSensitive indicates whether the fact names are case-sensitive.
+var caseSensitive atomic.Bool
+
+// hashed is set once a name with upper-case letters is hashed, after which the case
+// sensitivity can't change
+var hashed atomic.Bool
+
+// aliases maps the alias facts to the facts they resolve to.
+var aliases struct {
+	sync.RWMutex
+	facts map[fact]fact
+}
+
+// SetFactCacheSize sets the maximum number of fact names retained for printing. Once
+// the cache is full, the oldest names are evicted and their facts are printed as
+// "unknown". This does not affect planning, only the string representation of facts.
+func SetFactCacheSize(size int) {
+	factCache.Resize(size)
+	ruleCache.Reset()
+}
+
+// SetRuleCacheSize sets the maximum number of parsed rules retained, so that repeatedly
+// constructing the same states, for example when defining the actions of every spawned
+// agent, skips parsing entirely. Once the cache is full, it is cleared. A size of 0
+// disables the cache.
+func SetRuleCacheSize(size int) {
+	ruleCache.Resize(size)
+}
+
+// SetCaseSensitive sets whether the fact names are case-sensitive. By default, the names
+// are case-insensitive, so "Ammo" and "ammo" are the same fact and are printed with the
+// last spelling used. Since the facts are hashed when parsed, regardless of the domain
+// they are used in, the setting applies to the whole process and must be set once,
+// typically from an init function. It returns ErrCaseSensitive once any name with
+// upper-case letters was parsed, since the facts parsed differently are not comparable.
+func SetCaseSensitive(enabled bool) error {
+	if hashed.Load() {
+		return ErrCaseSensitive
+	}
+
+	caseSensitive.Store(enabled)
+	return nil
+}
 
 // ------------------------------------ Fact ------------------------------------
 
 // fact represents a state fact.
 type fact uint32
 
-// factOf creates a new fact from a string.
+// AliasFact registers an alias name resolving to the same fact as the name, for example
+// "hp" for "health", so domains assembled from multiple authors do not silently split
+// one concept into two facts. Aliases must be registered before parsing any rule using
+// them, and an alias cannot be redefined to resolve to a different fact.
+func AliasFact(alias, name string) error {
+	from := nameHash(alias)
+	into := factOf(name)
+
+	aliases.Lock()
+	defer aliases.Unlock()
+	if aliases.facts == nil {
+		aliases.facts = make(map[fact]fact, 8)
+	}
+
+	switch existing, ok := aliases.facts[from]; {
+	case from == into:
+		return fmt.Errorf("plan: cannot alias '%s' to '%s', both already resolve to the same fact", alias, name)
+	case ok && existing != into:
+		return fmt.Errorf("plan: alias '%s' already resolves to '%s'", alias, existing)
+	}
+
+	// Aliases of the alias now resolve to the new fact as well
+	for k, v := range aliases.facts {
+		if v == from {
+			aliases.facts[k] = into
+		}
+	}
+
+	aliases.facts[from] = into
+	ruleCache.Reset()
+	return nil
+}
+
+// nameHash returns the fact of the name, without resolving its alias. It is never seeded,
+// since the facts are shared by every domain and search.
+func nameHash(s string) fact {
+	lower := strings.ToLower(s)
+	if lower != s && !hashed.Load() {
+		hashed.Store(true) // Only the names with upper-case letters depend on the setting
+	}
+
+	if caseSensitive.Load() {
+		return fact(xxh3.HashString(s))
+	}
+	return fact(xxh3.HashString(lower))
+}
+
+// factOf creates a new fact from a string, resolving its alias if any.
 func factOf(s string) fact {
-	f := fact(xxh3.HashString(strings.ToLower(s)))
+	f := nameHash(s)
+	if target, ok := resolve(f); ok {
+		return target
+	}
+
+	// The names are only used for printing, so the cached rules are kept even if the name
+	// of their fact is evicted, rather than clearing them on every new name.
 	factCache.Store(f, s)
 	return f
 }
 
+// resolve returns the fact the alias resolves to, if the fact is an alias.
+func resolve(f fact) (fact, bool) {
+	aliases.RLock()
+	defer aliases.RUnlock()
+	target, ok := aliases.facts[f]
+	return target, ok
+}
+
 // String returns the string representation of the fact.
 func (f fact) String() string {
 	if v, ok := factCache.Load(f); ok {
-		return v.(string)
+		return v
 	}
 	return "unknown"
 }
 
-// parseRule parses an expression containing a fact and a rule
+// ------------------------------------ Name Cache ------------------------------------
+
+// nameCache is a bounded cache of fact names which evicts the oldest entries first,
+// so that dynamically generated fact names do not grow the memory indefinitely.
+type nameCache struct {
+	lock  sync.RWMutex
+	names map[fact]string
+	order []fact // Ring buffer of the facts, in insertion order
+	next  int    // Next position in the ring buffer to evict
+	limit int    // Maximum number of names in the cache
+}
+
+// newNameCache creates a new name cache with the specified capacity.
+func newNameCache(limit int) *nameCache {
+	c := new(nameCache)
+	c.Resize(limit)
+	return c
+}
+
+// Load returns the name of the fact, if present.
+func (c *nameCache) Load(f fact) (string, bool) {
+	c.lock.RLock()
+	v, ok := c.names[f]
+	c.lock.RUnlock()
+	return v, ok
+}
+
+// Store stores the name of the fact, evicting the oldest name if the cache is full.
+func (c *nameCache) Store(f fact, name string) {
+	if v, ok := c.Load(f); ok && v == name {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Clone the name so we don't retain the memory of a larger string. If the
+	// fact was already present, only its spelling is updated.
+	_, exists := c.names[f]
+	c.names[f] = strings.Clone(name)
+	switch {
+	case exists:
+		return
+	case len(c.order) < c.limit:
+		c.order = append(c.order, f)
+		return
+	}
+
+	// The cache is full, replace the oldest entry
+	delete(c.names, c.order[c.next])
+	c.order[c.next] = f
+	c.next = (c.next + 1) % len(c.order)
+}
+
+// Resize changes the capacity of the cache, clearing its contents.
+func (c *nameCache) Resize(limit int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.limit = max(limit, 1)
+	c.names = make(map[fact]string, min(c.limit, 1024))
+	c.order = make([]fact, 0, min(c.limit, 1024))
+	c.next = 0
+}
+
+// ------------------------------------ Rule Cache ------------------------------------
+
+// parseCache is a bounded cache of the parsed rules, keyed by their string. It is cleared
+// once full, as well as whenever the parsing of the rules may change, for example when
+// an alias is registered.
+type parseCache struct {
+	lock  sync.RWMutex
+	rules map[string]Rule
+	limit int // Maximum number of rules in the cache, 0 if disabled
+}
+
+// newParseCache creates a new parse cache with the specified capacity.
+func newParseCache(limit int) *parseCache {
+	c := new(parseCache)
+	c.Resize(limit)
+	return c
+}
+
+// Load returns the parsed rule, if present.
+func (c *parseCache) Load(s string) (Rule, bool) {
+	c.lock.RLock()
+	r, ok := c.rules[s]
+	c.lock.RUnlock()
+	return r, ok
+}
+
+// Store stores the parsed rule, clearing the cache if it is full.
+func (c *parseCache) Store(s string, rule Rule) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	switch {
+	case c.limit == 0:
+		return
+	case len(c.rules) >= c.limit:
+		clear(c.rules)
+	}
+
+	// Clone the string so we don't retain the memory of a larger one
+	c.rules[strings.Clone(s)] = rule
+}
+
+// Reset clears the contents of the cache.
+func (c *parseCache) Reset() {
+	c.lock.Lock()
+	clear(c.rules)
+	c.lock.Unlock()
+}
+
+// Resize changes the capacity of the cache, clearing its contents.
+func (c *parseCache) Resize(limit int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.limit = max(limit, 0)
+	c.rules = make(map[string]Rule, min(c.limit, 1024))
+}
+
+// ParseError represents an error that occurred while parsing a rule.
+type ParseError struct {
+	Rule       string // The rule that failed to parse
+	Offset     int    // Byte offset of the offending token in the rule
+	Token      string // The offending token
+	Reason     string // The reason of the failure
+	Suggestion string // Optional suggestion on how to fix the rule
+	Err        error  // Optional underlying error
+}
+
+// Error returns the error message.
+func (e *ParseError) Error() string {
+	if e.Rule == "" {
+		return "plan: " + e.Reason
+	}
+
+	msg := fmt.Sprintf("plan: %s '%s' at offset %d in rule '%s'", e.Reason, e.Token, e.Offset, e.Rule)
+	if e.Suggestion != "" {
+		msg += ", " + e.Suggestion
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error, if any.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// errorAt creates a new parse error for the token starting at the specified offset.
+func errorAt(s string, offset, end int, reason, suggestion string) error {
+	return &ParseError{
+		Rule:       s,
+		Offset:     offset,
+		Token:      s[offset:end],
+		Reason:     reason,
+		Suggestion: suggestion,
+	}
+}
+
+// parseRule parses an expression containing a fact and a rule, reusing the result of any
+// previous parsing of the same expression.
 func parseRule(s string) (fact, expr, error) {
+	if r, ok := ruleCache.Load(s); ok {
+		return r.Fact(), r.Expr(), nil
+	}
+
+	f, e, err := parse(s)
+	if err == nil {
+		ruleCache.Store(s, ruleOf(f, e))
+	}
+	return f, e, err
+}
+
+// parse parses an expression containing a fact and a rule
+func parse(s string) (fact, expr, error) {
 	length := len(s)
 	if length == 0 {
-		return 0, 0, fmt.Errorf("plan: rule is an empty string")
+		return 0, 0, &ParseError{Reason: "rule is an empty string"}
 	}
 
 	key := [2]int{0, 0}   // [start, end]
@@ -48,7 +334,7 @@ func parseRule(s string) (fact, expr, error) {
 	// Check for initial '!'
 	if s[0] == '!' {
 		if length == 1 {
-			return 0, 0, fmt.Errorf("plan: invalid rule '%s'", s)
+			return 0, 0, errorAt(s, 0, 1, "missing fact after", "specify a fact to negate, e.g. '!food'")
 		}
 
 		op = opEqual
@@ -59,13 +345,9 @@ func parseRule(s string) (fact, expr, error) {
 		goto parseKey
 	}
 
-	// Parse the key in the form of [a-zA-Z_]+
+	// Parse the key in the form of [a-zA-Z0-9_]+, where dots may also appear inside the name.
 parseKey:
-	for ; i < length; i++ {
-		c := s[i]
-		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
-			continue
-		}
+	if i = nameEnd(s, i); i < length {
 		key[1] = i
 		goto parseOperator
 	}
@@ -74,6 +356,10 @@ parseKey:
 
 	// Parse the operator in the form of [=+-<>]
 parseOperator:
+	if key[1] == key[0] {
+		return 0, 0, errorAt(s, i, i+1, "missing fact before", "a rule must start with a fact, e.g. 'food"+s[i:]+"'")
+	}
+
 	switch s[i] {
 	case '=':
 		op = opEqual
@@ -85,35 +371,233 @@ parseOperator:
 		op = opLess
 	case '>':
 		op = opGreater
+	case '!':
+		return 0, 0, errorAt(s, i, i+1, "invalid operator", "negation is only supported as a prefix, e.g. '!"+s[:i]+"'")
+	case ' ', '\t':
+		return 0, 0, errorAt(s, i, i+1, "unexpected whitespace", "rules must not contain any whitespace")
 	default:
-		return 0, 0, fmt.Errorf("plan: invalid operator '%c' in rule '%s'", s[i], s)
+		return 0, 0, errorAt(s, i, i+1, "invalid operator", "supported operators are '=', '+', '-', '<' and '>'")
+	}
+
+	// Detect unsupported two-character operators, such as '>=' or '=='
+	if i+1 < length && s[i+1] == '=' {
+		return 0, 0, errorAt(s, i, i+2, "invalid operator",
+			fmt.Sprintf("did you mean '%c'? '%s' is not supported", s[i], s[i:i+2]))
 	}
 
 	i++
 	valueStr = s[i:]
 
+	// The deltas may reference another fact, such as 'food+skill' or 'stamina-weight', which
+	// may also be enclosed in parentheses, such as 'stamina-(weight)'.
+	if (op == opIncrement || op == opDecrement) && i < length && (isLetter(s[i]) || s[i] == '(') {
+		name := valueStr
+		if s[i] == '(' {
+			if !strings.HasSuffix(name, ")") {
+				return 0, 0, errorAt(s, i, length, "unbalanced parentheses", "expected a referenced fact such as '(weight)'")
+			}
+			name, i = name[1:len(name)-1], i+1
+		}
+
+		if end := nameEnd(s, i); end-i != len(name) || len(name) == 0 {
+			return 0, 0, errorAt(s, min(end, length-1), min(end+1, length), "invalid character", "expected a referenced fact such as '(weight)'")
+		}
+
+		ref, err := refOf(factOf(name))
+		if err != nil {
+			return 0, 0, &ParseError{Rule: s, Offset: i, Token: valueStr, Reason: "too many referenced facts", Err: err}
+		}
+
+		return factOf(s[key[0]:key[1]]), exprOfRef(op, ref), nil
+	}
+
+	// A range is expressed with both of its bounds, such as 'temp>20<80'
+	f := factOf(s[key[0]:key[1]])
+	if j := strings.IndexByte(valueStr, '<'); op == opGreater && j >= 0 {
+		return parseBand(s, f, i, i+j, length)
+	}
+
+	// An uncertain effect is expressed with the range of its change, such as 'food+5..15'
+	if j := strings.Index(valueStr, ".."); (op == opIncrement || op == opDecrement) && j >= 0 {
+		return parseSpread(s, f, op, i, i+j, length)
+	}
+
+	v, err := parseValue(s, f, op, i, length)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return f, exprOfFixed(op, v), nil
+}
+
+// parseBand parses the bounds of a range, the lower one starting at the offset and the
+// upper one after the separator, and registers the range.
+func parseBand(s string, f fact, offset, separator, end int) (fact, expr, error) {
+	lo, err := parseValue(s, f, opGreater, offset, separator)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	hi, err := parseValue(s, f, opLess, separator+1, end)
+	switch {
+	case err != nil:
+		return 0, 0, err
+	case hi-lo < 2: // Both bounds are exclusive
+		return 0, 0, errorAt(s, offset, end, "empty range", "the lower bound must be below the upper bound")
+	}
+
+	band, err := bandOf(lo, hi)
+	if err != nil {
+		return 0, 0, &ParseError{Rule: s, Offset: offset, Token: s[offset:end], Reason: "too many ranges", Err: err}
+	}
+
+	return f, exprOfBand(band), nil
+}
+
+// parseSpread parses the range of the change of an uncertain effect, the lower bound starting
+// at the offset and the upper one after the separator, and registers the range.
+func parseSpread(s string, f fact, op operator, offset, separator, end int) (fact, expr, error) {
+	lo, err := parseValue(s, f, op, offset, separator)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	hi, err := parseValue(s, f, op, separator+2, end)
+	switch {
+	case err != nil:
+		return 0, 0, err
+	case hi <= lo:
+		return 0, 0, errorAt(s, offset, end, "empty range", "the lower bound must be below the upper bound")
+	}
+
+	band, err := bandOf(lo, hi)
+	if err != nil {
+		return 0, 0, &ParseError{Rule: s, Offset: offset, Token: s[offset:end], Reason: "too many ranges", Err: err}
+	}
+
+	if op == opIncrement {
+		return f, exprOfSpread(opIncrementAny, band), nil
+	}
+	return f, exprOfSpread(opDecrementAny, band), nil
+}
+
+// parseValue parses the value of the fact between the offsets as a fixed-point value. The
+// facts with a declared unit are expressed in their own range.
+func parseValue(s string, f fact, op operator, offset, end int) (int32, error) {
+	valueStr := s[offset:end]
+	d, declared := domainOf(f)
+	lo, hi := float64(MinValue), float64(MaxValue)
+	if declared {
+		valueStr = d.trim(valueStr)
+		l, h := d.bounds(op)
+		lo, hi = float64(l), float64(h)
+	}
+
 	// Parse the floating-point value
-	val, err := strconv.ParseFloat(valueStr, 32)
-	if err != nil || value < valueMin || value > valueMax {
-		return 0, 0, fmt.Errorf("plan: invalid value '%s' in rule '%s'", valueStr, s)
+	val, err := strconv.ParseFloat(valueStr, 64)
+	switch {
+	case err != nil || math.IsNaN(val):
+		return 0, errorAt(s, offset, end, "invalid value", fmt.Sprintf("expected a number between %v and %v", lo, hi))
+	case val < lo || val > hi:
+		return 0, &ParseError{
+			Rule:       s,
+			Offset:     offset,
+			Token:      valueStr,
+			Reason:     "value out of range",
+			Suggestion: fmt.Sprintf("expected a number between %v and %v", lo, hi),
+			Err:        ErrValueRange,
+		}
+	case declared:
+		val = float64(d.normalize(op, float32(val)))
+	}
+
+	return fixedOf(val), nil
+}
+
+// nameEnd returns the end of the fact name starting at the offset. Dots may appear inside
+// the name, but not dashes, since a dash is always the decrement operator (e.g. 'ammo-5'
+// or 'stamina-weight').
+func nameEnd(s string, offset int) int {
+	i := offset
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isNameChar(c):
+			continue
+		case c == '.' && i > offset && i+1 < len(s) && isNameChar(s[i+1]):
+			continue
+		}
+		return i
 	}
+	return i
+}
 
-	return factOf(s[key[0]:key[1]]), exprOf(op, float32(val)), nil
+// isLetter returns whether the character is a letter or an underscore.
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
+
+// isNameChar returns whether the character can be used anywhere in a fact name.
+func isNameChar(c byte) bool {
+	return isLetter(c) || (c >= '0' && c <= '9')
 }
 
 // ------------------------------------ Expression ------------------------------------
 
+// Precision represents the resolution at which the values of the facts are stored.
+type Precision uint8
+
 const (
-	valueMin = 0
-	valueMax = 100
+	PrecisionHundredths Precision = iota // Values are stored in hundredths (default)
+	PrecisionMillionths                  // Values are stored in millionths
 )
 
+// scale is the fixed-point scale, values are stored in hundredths unless set otherwise
+var scale int32 = 100
+
+// sealed is set once the first value is scaled, after which the precision can't change
+var sealed atomic.Bool
+
+// SetPrecision sets the resolution at which the values of the facts are stored. By default,
+// values are stored in hundredths, so simulation-heavy domains applying hundreds of small
+// effects, such as "hunger+0.004", visibly drift as every one of them is rounded. In
+// millionths, the arithmetic on the values remains exact at that resolution. The precision
+// applies to the whole process and must be set once, typically from an init function,
+// before any goroutine parses a rule. It returns ErrPrecision once any value was parsed,
+// since the rules parsed with a different precision are not comparable.
+func SetPrecision(precision Precision) error {
+	if sealed.Load() {
+		return ErrPrecision
+	}
+
+	switch precision {
+	case PrecisionMillionths:
+		scale = 1000000
+	default:
+		scale = 100
+	}
+	return nil
+}
+
+// fixedOf converts the value to fixed-point at the current precision, sealing it.
+func fixedOf(value float64) int32 {
+	if !sealed.Load() {
+		sealed.Store(true)
+	}
+	return int32(math.Round(value * float64(scale)))
+}
+
 const (
 	opEqual operator = iota
 	opIncrement
 	opDecrement
 	opLess
 	opGreater
+	opIncrementRef // Increment by the value of the referenced fact
+	opDecrementRef // Decrement by the value of the referenced fact
+	opBetween      // Strictly between the bounds of the referenced range
+	opIncrementAny // Increment by an uncertain value within the referenced range
+	opDecrementAny // Decrement by an uncertain value within the referenced range
 )
 
 type operator uint32
@@ -121,9 +605,9 @@ type operator uint32
 // String returns the string representation of the operator.
 func (o operator) String() string {
 	switch o {
-	case opIncrement:
+	case opIncrement, opIncrementRef, opIncrementAny:
 		return "+"
-	case opDecrement:
+	case opDecrement, opDecrementRef, opDecrementAny:
 		return "-"
 	case opLess:
 		return "<"
@@ -138,19 +622,29 @@ func (o operator) String() string {
 
 // expr represents an expression, expressed as a fixed point between 0 and 100.00,
 // the value can also be a delta (+/-) from the current value or a comparison operator
-// first 4 bits are used to indicate the type of the expr (operator).
+// first 4 bits are used to indicate the type of the expr (operator). The value is kept
+// as an integer number of hundredths (or millionths), so the arithmetic on it is exact.
 // [0-3]  - operator
-// [4-15] - unused
-// [16-31] - value
+// [4-31] - value (in hundredths or millionths, see SetPrecision)
 type expr uint32
 
-// exprOf creates a new expression from an operator and a value.
+// valueMask is the mask of the value bits of an expression.
+const valueMask = 0x0FFFFFFF
+
+// exprOf creates a new expression from an operator and a value, rounded to the
+// nearest step of the precision.
 func exprOf(op operator, value float32) expr {
-	if value < 0 {
-		value = 0
+	return exprOfFixed(op, fixedOf(float64(value)))
+}
+
+// exprOfFixed creates a new expression from an operator and a fixed-point value
+// expressed in steps of the precision. Values outside of the range saturate to its bounds.
+func exprOfFixed(op operator, value int32) expr {
+	if value < MinValue*scale {
+		value = MinValue * scale
 	}
-	if value > 100 {
-		value = 100
+	if value > MaxValue*scale {
+		value = MaxValue * scale
 	}
 	return expr(uint32(op)<<28 | uint32(value))
 }
@@ -162,30 +656,208 @@ func (e expr) Operator() operator {
 
 // Value returns the value of the effect.
 func (e expr) Value() float32 {
-	return float32(e & 0xFFFF)
+	return float32(float64(e.Fixed()) / float64(scale))
+}
+
+// Fixed returns the exact fixed-point value of the effect, in steps of the precision.
+func (e expr) Fixed() int32 {
+	return int32(e & valueMask)
 }
 
 // String returns the string representation of the effect.
 func (e expr) String() string {
-	return e.Operator().String() + strconv.FormatUint(uint64(e.Value()), 10)
+	if f, ok := e.Ref(); ok {
+		return e.Operator().String() + "(" + f.String() + ")"
+	}
+	switch lo, hi, ok := e.Band(); {
+	case ok && e.Operator() == opBetween:
+		return ">" + formatFixed(lo) + "<" + formatFixed(hi)
+	case ok:
+		return e.Operator().String() + formatFixed(lo) + ".." + formatFixed(hi)
+	default:
+		return e.Operator().String() + formatFixed(e.Fixed())
+	}
+}
+
+// formatFixed returns the string representation of a fixed-point value.
+func formatFixed(v int32) string {
+	return strconv.FormatFloat(float64(v)/float64(scale), 'f', -1, 64)
+}
+
+// ------------------------------------ References ------------------------------------
+
+// refs is the registry of the facts referenced by the expressions, since an expression
+// has no room for a fact and instead keeps its index in the registry.
+var refs struct {
+	sync.RWMutex
+	facts []fact
+	index map[fact]uint16
+}
+
+// maxRefs is the maximum number of distinct referenced facts.
+const maxRefs = 1 << 16
+
+// refOf returns the index of the referenced fact, registering it if needed.
+func refOf(f fact) (uint16, error) {
+	refs.RLock()
+	i, ok := refs.index[f]
+	refs.RUnlock()
+	if ok {
+		return i, nil
+	}
+
+	refs.Lock()
+	defer refs.Unlock()
+	if i, ok := refs.index[f]; ok {
+		return i, nil
+	}
+
+	if len(refs.facts) >= maxRefs {
+		return 0, fmt.Errorf("%w, at most %d facts can be referenced", ErrTooManyFacts, maxRefs)
+	}
+
+	if refs.index == nil {
+		refs.index = make(map[fact]uint16, 16)
+	}
+
+	i = uint16(len(refs.facts))
+	refs.facts = append(refs.facts, f)
+	refs.index[f] = i
+	return i, nil
+}
+
+// exprOfRef creates a new expression referencing another fact.
+func exprOfRef(op operator, ref uint16) expr {
+	if op == opIncrement {
+		op = opIncrementRef
+	} else {
+		op = opDecrementRef
+	}
+	return expr(uint32(op)<<28 | uint32(ref))
+}
+
+// Ref returns the fact referenced by the expression, if any.
+func (e expr) Ref() (fact, bool) {
+	if op := e.Operator(); op != opIncrementRef && op != opDecrementRef {
+		return 0, false
+	}
+
+	refs.RLock()
+	defer refs.RUnlock()
+	return refs.facts[e&0xFFFF], true
+}
+
+// ------------------------------------ Ranges ------------------------------------
+
+// band represents a range of fixed-point values, with exclusive bounds when required by a
+// rule and inclusive ones when changed by an uncertain effect.
+type band struct {
+	lo, hi int32
+}
+
+// bands is the registry of the ranges of the expressions, since an expression has no room
+// for both bounds and instead keeps the index of the range in the registry. The ranges are
+// never freed, since any rule may still refer to them, so the registry grows for the
+// lifetime of the process up to maxBands distinct ranges.
+var bands struct {
+	sync.RWMutex
+	ranges []band
+	index  map[band]uint16
+}
+
+// maxBands is the maximum number of distinct ranges.
+const maxBands = 1 << 16
+
+// bandOf returns the index of the range, registering it if needed.
+func bandOf(lo, hi int32) (uint16, error) {
+	b := band{lo: lo, hi: hi}
+	bands.RLock()
+	i, ok := bands.index[b]
+	bands.RUnlock()
+	if ok {
+		return i, nil
+	}
+
+	bands.Lock()
+	defer bands.Unlock()
+	if i, ok := bands.index[b]; ok {
+		return i, nil
+	}
+
+	if len(bands.ranges) >= maxBands {
+		return 0, fmt.Errorf("%w, at most %d ranges can be used", ErrTooManyRanges, maxBands)
+	}
+
+	if bands.index == nil {
+		bands.index = make(map[band]uint16, 16)
+	}
+
+	i = uint16(len(bands.ranges))
+	bands.ranges = append(bands.ranges, b)
+	bands.index[b] = i
+	return i, nil
+}
+
+// exprOfBand creates a new expression requiring a value within the range.
+func exprOfBand(index uint16) expr {
+	return expr(uint32(opBetween)<<28 | uint32(index))
+}
+
+// exprOfSpread creates a new expression changing a value by an uncertain delta within the range.
+func exprOfSpread(op operator, index uint16) expr {
+	return expr(uint32(op)<<28 | uint32(index))
+}
+
+// Band returns the fixed-point bounds of the range of the expression, if any.
+func (e expr) Band() (lo, hi int32, ok bool) {
+	if op := e.Operator(); op != opBetween && op != opIncrementAny && op != opDecrementAny {
+		return 0, 0, false
+	}
+
+	bands.RLock()
+	defer bands.RUnlock()
+	b := bands.ranges[e&0xFFFF]
+	return b.lo, b.hi, true
 }
 
 // ------------------------------------ Packed Data ------------------------------------
 
-type rule uint64
+// Rule represents a precompiled rule, packing both the fact and its expression. Rules
+// can be compiled once with CompileRule and reused to avoid parsing on hot paths.
+type Rule uint64
+
+// CompileRule parses the rule expression (e.g. "hunger>50") into a packed rule.
+func CompileRule(s string) (Rule, error) {
+	f, e, err := parseRule(s)
+	if err != nil {
+		return 0, err
+	}
+
+	return ruleOf(f, e), nil
+}
 
-func ruleOf(f fact, e expr) rule {
-	return rule(f)<<32 | rule(e)
+func ruleOf(f fact, e expr) Rule {
+	return Rule(f)<<32 | Rule(e)
 }
 
-func (e rule) Fact() fact {
+func (e Rule) Fact() fact {
 	return fact(e >> 32)
 }
 
-func (e rule) Expr() expr {
+func (e Rule) Expr() expr {
 	return expr(e & 0xFFFFFFFF)
 }
 
-func (e rule) Hash() uint32 {
+func (e Rule) Hash() uint32 {
 	return uint32(e.Fact()) | (uint32(e.Expr())*0xdeece66d + 0xb)
 }
+
+// String returns the string representation of the rule.
+func (e Rule) String() string {
+	if _, ref := e.Expr().Ref(); !ref {
+		if d, ok := domainOf(e.Fact()); ok {
+			return e.Fact().String() + d.format(e.Expr())
+		}
+	}
+	return e.Fact().String() + e.Expr().String()
+}
@@ -4,7 +4,10 @@
 package goap
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"os/exec"
 	"strings"
 	"testing"
 
@@ -13,33 +16,46 @@ import (
 
 func TestParse(t *testing.T) {
 	tests := map[string]string{
-		"hp":         "hp=100",
-		"!hp":        "hp=0",
-		"hp=10":      "hp=10",
-		"hp=10.5":    "hp=10",
-		"hp=10.":     "hp=10",
-		"hp-1":       "hp-1",
-		"hp+1":       "hp+1",
-		"hp+1.5":     "hp+1",
-		"hp-1.5":     "hp-1",
-		"hp=200":     "hp=100",
-		"hp=0":       "hp=0",
-		"hp=0.5":     "hp=0",
-		"hp=0.":      "hp=0",
-		"hp-0.0":     "hp-0",
-		"hp>10":      "hp>10",
-		"hp<10":      "hp<10",
-		"ammo_max":   "ammo_max=100",
-		"!ammo_max":  "ammo_max=0",
-		"ammo_Max=0": "ammo_Max=0",
-		"abc2":       "abc2=100",
-		"hp>=10":     "(error)",
-		"hp<=10":     "(error)",
-		"hp 2":       "(error)",
-		"hp=2.2.2":   "(error)",
-		"hp ":        "(error)",
-		"":           "(error)",
-		"!":          "(error)",
+		"hp":              "hp=100",
+		"!hp":             "hp=0",
+		"hp=10":           "hp=10",
+		"hp=10.5":         "hp=10.5",
+		"hp=10.":          "hp=10",
+		"hp-1":            "hp-1",
+		"hp+1":            "hp+1",
+		"hp+1.5":          "hp+1.5",
+		"hp-1.5":          "hp-1.5",
+		"hp=200":          "(error)",
+		"hp+101":          "(error)",
+		"hp=NaN":          "(error)",
+		"hp=0":            "hp=0",
+		"hp=0.5":          "hp=0.5",
+		"hp=0.":           "hp=0",
+		"hp=0.125":        "hp=0.13",
+		"hp-0.01":         "hp-0.01",
+		"hp-0.0":          "hp-0",
+		"hp>10":           "hp>10",
+		"hp<10":           "hp<10",
+		"ammo_max":        "ammo_max=100",
+		"!ammo_max":       "ammo_max=0",
+		"ammo_Max=0":      "ammo_Max=0",
+		"abc2":            "abc2=100",
+		"2abc":            "2abc=100",
+		"enemy1_visible":  "enemy1_visible=100",
+		"zone.3.cleared":  "zone.3.cleared=100",
+		"!zone.3.cleared": "zone.3.cleared=0",
+		"zone.3=50":       "zone.3=50",
+		"enemy-a_visible": "enemy-(a_visible)",
+		"enemy-a-1":       "(error)",
+		"zone.":           "(error)",
+		"zone..3":         "(error)",
+		"hp>=10":          "(error)",
+		"hp<=10":          "(error)",
+		"hp 2":            "(error)",
+		"hp=2.2.2":        "(error)",
+		"hp ":             "(error)",
+		"":                "(error)",
+		"!":               "(error)",
 	}
 
 	for input, expect := range tests {
@@ -54,6 +70,59 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		input  string
+		offset int
+		token  string
+		hint   string
+	}{
+		{"hp>=10", 2, ">=", "did you mean '>'"},
+		{"hp<=10", 2, "<=", "did you mean '<'"},
+		{"hp==10", 2, "==", "did you mean '='"},
+		{"hp!=10", 2, "!", "'!hp'"},
+		{"hp 2", 2, " ", "whitespace"},
+		{"hp=2.2.2", 3, "2.2.2", "number"},
+		{"hp*2", 2, "*", "supported operators"},
+		{"=10", 0, "=", "'food=10'"},
+		{"!", 0, "!", "'!food'"},
+	}
+
+	for _, test := range tests {
+		_, _, err := parseRule(test.input)
+		assert.Error(t, err, test.input)
+
+		var perr *ParseError
+		assert.True(t, errors.As(err, &perr), test.input)
+		assert.Equal(t, test.input, perr.Rule)
+		assert.Equal(t, test.offset, perr.Offset, test.input)
+		assert.Equal(t, test.token, perr.Token, test.input)
+		assert.Contains(t, perr.Error(), test.hint, test.input)
+	}
+}
+
+func TestParseValueRange(t *testing.T) {
+	_, _, err := parseRule("hp=100.5")
+	assert.ErrorIs(t, err, ErrValueRange)
+
+	_, _, err = parseRule("hp>-1")
+	assert.ErrorIs(t, err, ErrValueRange)
+
+	_, _, err = parseRule("hp=100")
+	assert.NoError(t, err)
+}
+
+func TestStateOfErrors(t *testing.T) {
+	defer func() {
+		err, ok := recover().(error)
+		assert.True(t, ok)
+		assert.Contains(t, err.Error(), "hp>=10")
+		assert.Contains(t, err.Error(), "ammo 2")
+	}()
+
+	StateOf("hp>=10", "food", "ammo 2")
+}
+
 func TestRuleHash(t *testing.T) {
 	tests := []struct {
 		rules  []string
@@ -84,6 +153,117 @@ func TestFactString(t *testing.T) {
 	assert.Equal(t, "unknown", fact(123).String())
 }
 
+func TestCompileRule(t *testing.T) {
+	r, err := CompileRule("hunger>50")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunger>50", r.String())
+
+	_, err = CompileRule("hunger>=50")
+	assert.Error(t, err)
+}
+
+func TestFactCacheEviction(t *testing.T) {
+	cache := newNameCache(4)
+	for i := 0; i < 1000; i++ {
+		name := fmt.Sprintf("entity%d", i)
+		cache.Store(fact(i), name)
+
+		v, ok := cache.Load(fact(i))
+		assert.True(t, ok)
+		assert.Equal(t, name, v)
+	}
+
+	assert.Len(t, cache.names, 4)
+	assert.Len(t, cache.order, 4)
+
+	// Only the most recent names are retained
+	_, ok := cache.Load(fact(995))
+	assert.False(t, ok)
+	for i := 996; i < 1000; i++ {
+		_, ok := cache.Load(fact(i))
+		assert.True(t, ok)
+	}
+}
+
+func TestSetFactCacheSize(t *testing.T) {
+	// Resizing evicts the names, so the names of the other tests are kept aside
+	names := factCache
+	factCache = newNameCache(defaultCacheSize)
+	t.Cleanup(func() {
+		factCache = names
+		ruleCache.Reset()
+	})
+
+	SetFactCacheSize(1)
+
+	a, b := factOf("A"), factOf("B")
+	assert.Equal(t, "unknown", a.String())
+	assert.Equal(t, "B", b.String())
+}
+
+func TestFactCacheKeepsRules(t *testing.T) {
+	names := factCache
+	factCache = newNameCache(1)
+	t.Cleanup(func() {
+		factCache = names
+		ruleCache.Reset()
+	})
+
+	// Evicting the name of a fact doesn't clear the parsed rules
+	_, err := CompileRule("cache_hunger>50")
+	assert.NoError(t, err)
+	factOf("cache_other")
+
+	_, ok := ruleCache.Load("cache_hunger>50")
+	assert.True(t, ok)
+}
+
+func TestRuleCache(t *testing.T) {
+	cache := newParseCache(2)
+	cache.Store("A", 1)
+	cache.Store("B", 2)
+
+	r, ok := cache.Load("B")
+	assert.True(t, ok)
+	assert.Equal(t, Rule(2), r)
+
+	// The cache is cleared once full
+	cache.Store("C", 3)
+	_, ok = cache.Load("A")
+	assert.False(t, ok)
+	_, ok = cache.Load("C")
+	assert.True(t, ok)
+
+	cache.Resize(0)
+	cache.Store("D", 4)
+	_, ok = cache.Load("D")
+	assert.False(t, ok)
+}
+
+func TestSetRuleCacheSize(t *testing.T) {
+	defer SetRuleCacheSize(defaultRuleCacheSize)
+	SetRuleCacheSize(0)
+
+	uncached, err := CompileRule("hunger>50")
+	assert.NoError(t, err)
+
+	SetRuleCacheSize(16)
+	for i := 0; i < 2; i++ {
+		rule, err := CompileRule("hunger>50")
+		assert.NoError(t, err)
+		assert.Equal(t, uncached, rule)
+	}
+
+	_, ok := ruleCache.Load("hunger>50")
+	assert.True(t, ok)
+
+	// Invalid rules are never cached
+	_, err = CompileRule("hunger>>50")
+	assert.Error(t, err)
+	_, ok = ruleCache.Load("hunger>>50")
+	assert.False(t, ok)
+}
+
 // ------------------------------------ Test Functions ------------------------------------
 
 func hashOf(s ...string) (h uint32) {
@@ -97,3 +277,151 @@ func hashOf(s ...string) (h uint32) {
 	}
 	return
 }
+
+func TestAliasFact(t *testing.T) {
+	// The aliases are never removed, so the names are unique to this test
+	assert.NoError(t, AliasFact("alias_hp", "alias_health"))
+	assert.NoError(t, AliasFact("alias_hp", "alias_health"))
+	assert.NoError(t, AliasFact("alias_life", "alias_hp"))
+
+	state := StateOf("alias_health=50")
+	assert.NoError(t, state.Apply(StateOf("alias_hp+20")))
+	assert.Equal(t, "{alias_health=70}", state.String())
+
+	match, err := state.Match(StateOf("alias_life>60"))
+	assert.NoError(t, err)
+	assert.True(t, match)
+
+	assert.Error(t, AliasFact("alias_hp", "alias_mana"))
+	assert.Error(t, AliasFact("alias_health", "alias_life"))
+}
+
+func TestCaseSensitive(t *testing.T) {
+	// The case sensitivity is process-wide, so it is changed in a separate test process
+	if os.Getenv("GOAP_CASE_SENSITIVE") == "" {
+		assert.Equal(t, StateOf("Ammo=10").Hash(), StateOf("ammo=10").Hash())
+		assert.ErrorIs(t, SetCaseSensitive(true), ErrCaseSensitive)
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCaseSensitive$")
+		cmd.Env = append(os.Environ(), "GOAP_CASE_SENSITIVE=1")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+		return
+	}
+
+	assert.NoError(t, SetCaseSensitive(true))
+	assert.NotEqual(t, StateOf("Ammo=10").Hash(), StateOf("ammo=10").Hash())
+	assert.Equal(t, "{Ammo=10}", StateOf("Ammo=10").String())
+	assert.Equal(t, "{ammo=10}", StateOf("ammo=10").String())
+}
+
+func TestParseBand(t *testing.T) {
+	rule, err := CompileRule("temp>20<80")
+	assert.NoError(t, err)
+	assert.Equal(t, "temp>20<80", rule.String())
+
+	for value, expect := range map[string]bool{
+		"temp=20": false, "temp=20.01": true, "temp=50": true,
+		"temp=79.99": true, "temp=80": false, "!temp": false,
+	} {
+		match, err := StateOf(value).Match(StateOfRules(rule))
+		assert.NoError(t, err)
+		assert.Equal(t, expect, match, value)
+	}
+
+	// The distance is the one to the closest bound
+	assert.Equal(t, float32(15), StateOf("temp=5").Distance(StateOfRules(rule)))
+	assert.Equal(t, float32(10), StateOf("temp=90").Distance(StateOfRules(rule)))
+	assert.Zero(t, StateOf("temp=50").Distance(StateOfRules(rule)))
+
+	for _, invalid := range []string{"temp>80<20", "temp>20<20.01", "temp>20<", "temp>20<200", "temp<20>80"} {
+		_, err := CompileRule(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	// A range can't be applied as an effect
+	assert.Error(t, StateOf("temp=50").Apply(StateOf("temp>20<80")))
+}
+
+func TestParseSpread(t *testing.T) {
+	for input, expect := range map[string]string{
+		"food+5..15":    "food+5..15",
+		"food-0.5..1.5": "food-0.5..1.5",
+	} {
+		rule, err := CompileRule(input)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, rule.String())
+	}
+
+	for _, invalid := range []string{"food+15..5", "food+5..5", "food+5..", "food+5..200", "food=5..15"} {
+		_, err := CompileRule(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	// Applying an uncertain effect assumes the middle of its range
+	state := StateOf("food=50", "hunger=50")
+	assert.NoError(t, state.Apply(StateOf("food+5..15", "hunger-10..20")))
+	assert.Equal(t, "{food=60, hunger=35}", state.String())
+}
+
+func TestParseReference(t *testing.T) {
+	for input, expect := range map[string]string{
+		"stamina-(weight)":    "stamina-(weight)",
+		"food+forage_skill":   "food+(forage_skill)",
+		"food+(forage_skill)": "food+(forage_skill)",
+		"food+zone.3":         "food+(zone.3)",
+		"stamina-weight":      "stamina-(weight)",
+		"food=forage_skill":   "(error)",
+		"food>forage_skill":   "(error)",
+		"food+forage>":        "(error)",
+		"food+(forage":        "(error)",
+		"food+()":             "(error)",
+		"food+(a)b)":          "(error)",
+	} {
+		rule, err := CompileRule(input)
+		if expect == "(error)" {
+			assert.Error(t, err, input)
+			continue
+		}
+
+		assert.NoError(t, err)
+		assert.Equal(t, expect, rule.String())
+	}
+
+	state := StateOf("stamina=50", "weight=20", "food=10", "forage_skill=35")
+	assert.NoError(t, state.Apply(StateOf("stamina-weight", "food+forage_skill")))
+	match, err := state.Match(StateOf("stamina=30", "food=45", "weight=20"))
+	assert.NoError(t, err)
+	assert.True(t, match)
+}
+
+func TestPrecision(t *testing.T) {
+	drift := func() string {
+		state := StateOf("hunger=0")
+		for i := 0; i < 250; i++ {
+			assert.NoError(t, state.Apply(StateOf("hunger+0.004")))
+		}
+		return state.String()
+	}
+
+	// The precision is process-wide, so it is changed in a separate test process
+	if os.Getenv("GOAP_PRECISION") == "" {
+		assert.Equal(t, "{hunger=0}", drift()) // In hundredths, every small effect is rounded away
+		assert.ErrorIs(t, SetPrecision(PrecisionMillionths), ErrPrecision)
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestPrecision$")
+		cmd.Env = append(os.Environ(), "GOAP_PRECISION=1")
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+		return
+	}
+
+	assert.NoError(t, SetPrecision(PrecisionMillionths))
+	assert.Equal(t, "{hunger=1}", drift())
+	assert.Equal(t, "{food=12.345678}", StateOf("food=12.345678").String())
+	assert.Equal(t, "{food=100}", StateOf("food").String())
+
+	match, err := StateOf("food=50.000001").Match(StateOf("food>50"))
+	assert.NoError(t, err)
+	assert.True(t, match)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "math"
+
+// WeightedGoal represents one of the goals of an opportunistic agent, along with the value
+// of achieving it, such as gathering food being worth more than chopping wood.
+type WeightedGoal struct {
+	Goal   *State  // The desired state
+	Weight float32 // The value of achieving the goal
+}
+
+// Satisfaction represents the best plan found for a set of weighted goals.
+type Satisfaction struct {
+	Plan      []Action // The plan to perform
+	Satisfied []int    // The indices of the goals satisfied once the plan is performed
+	Weight    float32  // The total weight of the satisfied goals
+	Cost      float32  // The total cost of the plan
+}
+
+// PlanWeighted finds the plan satisfying the largest total weight of the goals within the
+// cost budget, for example to "do as much as possible before nightfall", rather than
+// requiring every goal to be satisfied. Among the plans satisfying the same weight, the
+// cheapest one is returned. Without a budget, every state reachable from the start state
+// may be explored, hence a budget should always be provided for large domains.
+func PlanWeighted(start *State, goals []WeightedGoal, budget float32, actions []Action, opts ...Option) (Satisfaction, error) {
+	return satisfy(start, goals, budget, actions, optionsOf(opts))
+}
+
+// PlanWeighted finds the plan satisfying the largest total weight of the goals within the
+// cost budget.
+func (p *Planner) PlanWeighted(start *State, goals []WeightedGoal, budget float32, opts ...Option) (Satisfaction, error) {
+	return satisfy(start, goals, budget, p.actions, with(p.options, opts))
+}
+
+// satisfy runs a uniform-cost search within the budget, keeping track of the cheapest state
+// satisfying the largest total weight. The search stops early once every goal is satisfied.
+func satisfy(start *State, goals []WeightedGoal, budget float32, actions []Action, o options) (Satisfaction, error) {
+	var total float32
+	for _, g := range goals {
+		total += max(g.Weight, 0)
+	}
+
+	var best Satisfaction
+	var found bool
+	goal := objective{match: func(state *State) (bool, error) {
+		weight, satisfied, err := weigh(state, goals)
+		switch {
+		case err != nil:
+			return false, err
+		case !found || weight > best.Weight:
+			best = Satisfaction{
+				Plan:      reconstructPlan(nil, state),
+				Satisfied: satisfied,
+				Weight:    weight,
+				Cost:      state.stateCost,
+			}
+			found = true
+		}
+		return weight >= total, nil
+	}}
+
+	// The best plan is recorded once the search is over, as it may not reach every goal
+	analytics := o.analytics
+	o.analytics = nil
+	o.budget = budget
+	o.waypoints = nil
+	if budget <= 0 {
+		o.budget = math.MaxFloat32
+	}
+
+	var search Search
+	search.init(nil, start, goal, actions, o)
+	for !search.Step(math.MaxInt) {
+	}
+
+	if search.final != nil {
+		search.final.release()
+	}
+
+	// The search fails once every state within the budget is explored, which is expected
+	if _, err := search.Result(); err != nil && !search.drained {
+		return Satisfaction{}, err
+	}
+
+	if found {
+		analytics.record(best.Plan)
+	}
+	return best, nil
+}
+
+// weigh returns the total weight of the goals satisfied by the state, and their indices.
+func weigh(state *State, goals []WeightedGoal) (weight float32, satisfied []int, err error) {
+	for i, g := range goals {
+		ok, err := state.Match(g.Goal)
+		switch {
+		case err != nil:
+			return 0, nil, err
+		case ok:
+			weight += max(g.Weight, 0)
+			satisfied = append(satisfied, i)
+		}
+	}
+	return weight, satisfied, nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanWeighted(t *testing.T) {
+	start := StateOf("!food", "!wood", "!water")
+	goals := []WeightedGoal{
+		{Goal: StateOf("food"), Weight: 10},
+		{Goal: StateOf("wood"), Weight: 3},
+		{Goal: StateOf("water"), Weight: 5},
+	}
+	actions := []Action{
+		actionOf("hunt", 4, StateOf("!food"), StateOf("food")),
+		actionOf("chop", 2, StateOf("!wood"), StateOf("wood")),
+		actionOf("fetch", 3, StateOf("!water"), StateOf("water")),
+	}
+
+	// Only the most valuable goals fit within the budget
+	out, err := PlanWeighted(start, goals, 7, actions)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"hunt", "fetch"}, planOf(out.Plan))
+	assert.Equal(t, []int{0, 2}, out.Satisfied)
+	assert.Equal(t, float32(15), out.Weight)
+	assert.Equal(t, float32(7), out.Cost)
+
+	// With a larger budget, every goal is satisfied
+	out, err = PlanWeighted(start, goals, 100, actions)
+	assert.NoError(t, err)
+	assert.Len(t, out.Plan, 3)
+	assert.Equal(t, []int{0, 1, 2}, out.Satisfied)
+	assert.Equal(t, float32(9), out.Cost)
+
+	// Without any budget for an action, nothing is satisfied
+	out, err = PlanWeighted(start, goals, 1, actions)
+	assert.NoError(t, err)
+	assert.Empty(t, out.Plan)
+	assert.Empty(t, out.Satisfied)
+}
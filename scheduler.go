@@ -0,0 +1,202 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// minPriority is the priority by which every request ages, on top of its own priority.
+const minPriority = 0.01
+
+// Request represents a planning request of an agent, queued in a scheduler until one of
+// its ticks has enough budget left to plan it.
+type Request struct {
+	Agent    string                         // The unique name of the agent
+	Start    *State                         // The current state of the agent
+	Goal     *State                         // The goal of the agent
+	Priority float32                        // The importance of the agent, such as 1 for nearby ones
+	Done     func(plan []Action, err error) // The callback receiving the plan, called during a tick
+}
+
+// Tier represents the level of detail of the planning of an agent. Nearby agents
+// typically plan on every tick with a full search, while distant agents plan rarely
+// with a narrow beam search, so that large worlds stay within the CPU budget.
+type Tier struct {
+	Every int // The number of ticks between two plans of the agent, 0 or 1 for every tick
+	Beam  int // The maximum number of states in the open set of the search, 0 for a full search
+}
+
+// options returns the planner options of the tier.
+func (t Tier) options() []Option {
+	if t.Beam <= 0 {
+		return nil
+	}
+	return []Option{WithOpenLimit(t.Beam, PruneWorst)}
+}
+
+// lod represents the level of detail of an agent, along with the tick it was last planned.
+type lod struct {
+	Tier
+	last uint64 // The tick at which the agent was last planned, 0 if never
+}
+
+// pending represents a request waiting in the queue of the scheduler.
+type pending struct {
+	Request
+	since uint64 // The tick at which the agent was first queued
+}
+
+// Scheduler spreads the planning of many agents across frames. Every tick plans up to a
+// number of queued requests or until its time budget is spent, whichever comes first, and
+// leaves the rest queued for the following ticks. Requests are served by importance, the
+// priority of a request growing with the number of ticks it has been waiting, so that
+// unimportant agents are never starved. It is safe for concurrent use.
+type Scheduler struct {
+	lock    sync.Mutex
+	planner *Planner            // The planner used to find the plans
+	limit   int                 // Maximum number of plans per tick, 0 for unlimited
+	budget  time.Duration       // Maximum time spent planning per tick, 0 for unlimited
+	queue   map[string]*pending // The queued requests, by agent
+	tiers   map[string]*lod     // The levels of detail of the agents, by agent
+	tick    uint64              // The number of ticks so far
+}
+
+// NewScheduler creates a new scheduler which plans up to limit requests per tick, and
+// stops planning once the budget is spent. A zero limit or budget is unlimited, and at
+// least one request is planned on every tick regardless of the budget.
+func NewScheduler(planner *Planner, limit int, budget time.Duration) *Scheduler {
+	return &Scheduler{
+		planner: planner,
+		limit:   max(limit, 0),
+		budget:  max(budget, 0),
+		queue:   make(map[string]*pending, 16),
+		tiers:   make(map[string]*lod, 16),
+	}
+}
+
+// Submit queues the planning request of an agent. If the agent already has a queued
+// request, it is replaced by the new one but keeps its place, so that agents which keep
+// resubmitting as the world changes are not starved either.
+func (s *Scheduler) Submit(r Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if p, ok := s.queue[r.Agent]; ok {
+		p.Request = r
+		return
+	}
+
+	s.queue[r.Agent] = &pending{Request: r, since: s.tick}
+}
+
+// Cancel removes the queued request of the agent, if any.
+func (s *Scheduler) Cancel(agent string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.queue, agent)
+}
+
+// SetTier sets the level of detail of the agent, which can be changed at any time, for
+// example as the agent moves closer to the player. The agents without a tier plan on
+// every tick with a full search.
+func (s *Scheduler) SetTier(agent string, tier Tier) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if l, ok := s.tiers[agent]; ok {
+		l.Tier = tier
+		return
+	}
+
+	s.tiers[agent] = &lod{Tier: tier}
+}
+
+// Len returns the number of queued requests.
+func (s *Scheduler) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.queue)
+}
+
+// Tick plans the most important queued requests within the limits of the scheduler and
+// calls their callbacks, typically once per frame. It returns the number of requests
+// planned during the tick.
+func (s *Scheduler) Tick() int {
+	began := time.Now()
+	batch := s.next()
+
+	planned := 0
+	for _, r := range batch {
+		if planned > 0 && s.budget > 0 && time.Since(began) >= s.budget {
+			break
+		}
+
+		s.lock.Lock()
+		var opts []Option
+		current, ok := s.queue[r.Agent]
+		if ok {
+			delete(s.queue, r.Agent)
+			if l, tiered := s.tiers[r.Agent]; tiered {
+				opts = l.options()
+				l.last = s.tick
+			}
+		}
+		s.lock.Unlock()
+
+		// The request was cancelled while planning the previous ones
+		if !ok {
+			continue
+		}
+
+		plan, err := s.planner.Plan(current.Start, current.Goal, opts...)
+		if current.Done != nil {
+			current.Done(plan, err)
+		}
+		planned++
+	}
+
+	return planned
+}
+
+// next advances the tick and returns the queued requests by decreasing importance,
+// up to the limit of plans per tick, leaving queued the agents whose tier is not due.
+func (s *Scheduler) next() []*pending {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.tick++
+	batch := make([]*pending, 0, len(s.queue))
+	for _, p := range s.queue {
+		if s.due(p.Agent) {
+			batch = append(batch, p)
+		}
+	}
+
+	sort.Slice(batch, func(i, j int) bool {
+		a, b := s.score(batch[i]), s.score(batch[j])
+		if a != b {
+			return a > b
+		}
+		return batch[i].Agent < batch[j].Agent
+	})
+
+	if s.limit > 0 && len(batch) > s.limit {
+		batch = batch[:s.limit]
+	}
+	return batch
+}
+
+// score returns the importance of the request, its priority growing with the number
+// of ticks it has been waiting for. Every request ages at least by the minimum priority,
+// so that the requests without any priority still win over the others eventually.
+func (s *Scheduler) score(p *pending) float32 {
+	return (max(p.Priority, 0) + minPriority) * float32(s.tick-p.since)
+}
+
+// due returns whether the agent may be planned on the current tick given its tier.
+func (s *Scheduler) due(agent string) bool {
+	l, ok := s.tiers[agent]
+	return !ok || l.last == 0 || s.tick-l.last >= uint64(max(l.Every, 1))
+}
@@ -0,0 +1,111 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler(t *testing.T) {
+	planner, err := NewPlanner([]Action{move("A->B"), move("B->C")})
+	assert.NoError(t, err)
+
+	var order []string
+	done := func(agent string) func([]Action, error) {
+		return func(plan []Action, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"A->B", "B->C"}, planOf(plan))
+			order = append(order, agent)
+		}
+	}
+
+	s := NewScheduler(planner, 2, 0)
+	s.Submit(Request{Agent: "low", Start: StateOf("A"), Goal: StateOf("C"), Priority: 1, Done: done("low")})
+	s.Submit(Request{Agent: "high", Start: StateOf("A"), Goal: StateOf("C"), Priority: 3, Done: done("high")})
+	s.Submit(Request{Agent: "mid", Start: StateOf("A"), Goal: StateOf("C"), Priority: 2, Done: done("mid")})
+	assert.Equal(t, 3, s.Len())
+
+	// Only the most important requests are planned on the first tick
+	assert.Equal(t, 2, s.Tick())
+	assert.Equal(t, []string{"high", "mid"}, order)
+	assert.Equal(t, 1, s.Len())
+
+	// The waiting request eventually wins over the more important new one
+	s.Submit(Request{Agent: "new", Start: StateOf("A"), Goal: StateOf("C"), Priority: 1.5, Done: done("new")})
+	assert.Equal(t, 2, s.Tick())
+	assert.Equal(t, []string{"high", "mid", "low", "new"}, order)
+	assert.Equal(t, 0, s.Tick())
+}
+
+func TestSchedulerStarvation(t *testing.T) {
+	planner, err := NewPlanner([]Action{move("A->B")})
+	assert.NoError(t, err)
+
+	var idle bool
+	s := NewScheduler(planner, 1, 0)
+	s.Submit(Request{Agent: "idle", Start: StateOf("A"), Goal: StateOf("B"), Done: func([]Action, error) {
+		idle = true
+	}})
+
+	// A busy agent resubmits on every tick, yet the agent without priority is planned
+	for i := 0; i < 200 && !idle; i++ {
+		s.Submit(Request{Agent: "busy", Start: StateOf("A"), Goal: StateOf("B"), Priority: 1})
+		assert.Equal(t, 1, s.Tick())
+	}
+	assert.True(t, idle)
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	planner, err := NewPlanner([]Action{move("A->B")})
+	assert.NoError(t, err)
+
+	s := NewScheduler(planner, 0, 0)
+	s.Submit(Request{Agent: "a", Start: StateOf("A"), Goal: StateOf("B"), Priority: 1})
+	s.Submit(Request{Agent: "a", Start: StateOf("A"), Goal: StateOf("B"), Priority: 2})
+	assert.Equal(t, 1, s.Len())
+
+	s.Cancel("a")
+	assert.Equal(t, 0, s.Len())
+	assert.Equal(t, 0, s.Tick())
+}
+
+func TestSchedulerTier(t *testing.T) {
+	planner, err := NewPlanner([]Action{move("A->B"), move("B->C"), move("A->D"), move("D->C", 3)})
+	assert.NoError(t, err)
+
+	var order []string
+	done := func(agent string) func([]Action, error) {
+		return func(plan []Action, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "C", plan[len(plan)-1].(*testAction).name[3:])
+			order = append(order, agent)
+		}
+	}
+
+	s := NewScheduler(planner, 0, 0)
+	s.SetTier("far", Tier{Every: 3, Beam: 1})
+	submit := func() {
+		s.Submit(Request{Agent: "near", Start: StateOf("A"), Goal: StateOf("C"), Priority: 2, Done: done("near")})
+		s.Submit(Request{Agent: "far", Start: StateOf("A"), Goal: StateOf("C"), Priority: 1, Done: done("far")})
+	}
+
+	// Both agents are planned on the first tick, then the distant one only every 3 ticks
+	for i := 0; i < 4; i++ {
+		submit()
+		s.Tick()
+	}
+	assert.Equal(t, []string{"near", "far", "near", "near", "far", "near"}, order)
+	assert.Equal(t, 0, s.Len())
+
+	// The distant agent comes closer and plans on every tick
+	order = order[:0]
+	s.SetTier("far", Tier{})
+	submit()
+	s.Tick()
+	submit()
+	s.Tick()
+	assert.Equal(t, []string{"near", "far", "near", "far"}, order)
+}
@@ -0,0 +1,658 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+var errNotDone = errors.New("plan: search is not done")
+
+// Search represents a resumable search for a plan. It allows to spread a single
+// expensive search across multiple frames by calling Step() with a number of nodes
+// to expand on every frame, and to fetch the plan with Result() once done.
+type Search struct {
+	dst     []Action  // The plan being constructed
+	origin  *State    // The start state provided by the caller
+	current *State    // The start state of the current segment
+	goal    objective // The final goal of the search
+	actions []Action  // The set of actions
+	options options   // The options of the search
+	stats   Counters  // The profiling counters
+	trace   *tracer   // The timeline of the search, nil unless tracing
+	hits    []bool    // Whether each of the breakpoints was hit
+	heap    *graph    // The graph of the current segment, nil if not started
+	root    *State    // The start node of the current segment, owned by the graph
+	segment int       // The index of the current segment (waypoint)
+	pruned  bool      // Whether any branch was pruned due to the budget
+	invalid bool      // Whether any branch was pruned due to the constraints
+	drained bool      // Whether the search failed as no state was left to expand
+	done    bool      // Whether the search is done
+	err     error     // The error of the search, if any
+	final   *State    // The final state, once the search is done
+	steps   []Step    // The predicted states of every step, if recorded
+	factors []float32 // The multipliers of the cost of the actions, if any
+	include []uint64  // The bit of every action required in the plan, if any
+	require uint64    // The bits of all the actions required in the plan
+	best    *State    // The expanded node closest to the goal, owned by the graph
+	cost    float32   // The cost of the plan found so far, as accounted by the search
+	touched uint64    // The facts of the goal written by any outcome simulated in the segment
+	used    []float32 // The amount of every capped resource spent by the plan before the segment
+	odds    float32   // The probability of the plan before the segment succeeding
+}
+
+// Start starts a resumable search for a plan to reach the goal from the start state.
+// The start state is copied right away, so the caller may modify it once Start returns,
+// but the search does not expand any node until Step() is called.
+func (p *Planner) Start(start, goal *State, opts ...Option) *Search {
+	s := new(Search)
+	s.init(nil, start, goalOf(goal), p.actions, with(p.options, opts))
+	if !s.done {
+		if s.options.recover {
+			defer s.recover()
+		}
+		s.begin()
+	}
+	return s
+}
+
+// init initializes the search.
+func (s *Search) init(dst []Action, start *State, goal objective, actions []Action, o options) {
+	if o.objects != nil {
+		actions = append(actions[:len(actions):len(actions)], o.objects.Actions(o.near)...)
+	}
+
+	if o.filter != nil {
+		actions = filter(actions, o.filter)
+	}
+
+	if len(o.avoid) > 0 {
+		actions = avoid(actions, o.avoid)
+	}
+
+	*s = Search{
+		dst:     dst,
+		origin:  start,
+		current: start,
+		goal:    goal,
+		actions: actions,
+		options: o,
+		trace:   newTracer(o.trace),
+		factors: o.personality.factors(actions),
+	}
+
+	if len(actions) > MaxActions {
+		s.finish(fmt.Errorf("%w, %d actions provided", ErrTooManyActions, len(actions)))
+		return
+	}
+
+	if len(o.include) > 0 {
+		var err error
+		if s.include, s.require, err = included(actions, o.include); err != nil {
+			s.finish(err)
+		}
+	}
+}
+
+// Step expands up to n nodes of the search graph and returns whether the search is done.
+func (s *Search) Step(n int) bool {
+	if s.done {
+		return true
+	}
+
+	if s.options.recover {
+		defer s.recover()
+	}
+
+	for i := 0; i < n && !s.done; i++ {
+		s.expand()
+	}
+	return s.done
+}
+
+// Done returns whether the search is done.
+func (s *Search) Done() bool {
+	return s.done
+}
+
+// Result returns the plan found by the search, or an error if no plan was found or the
+// search is not done yet.
+func (s *Search) Result() ([]Action, error) {
+	switch {
+	case !s.done:
+		return nil, errNotDone
+	case s.err != nil:
+		return nil, s.err
+	default:
+		return s.dst, nil
+	}
+}
+
+// Close abandons the search and releases its resources.
+func (s *Search) Close() {
+	if !s.done {
+		s.finish(errors.New("plan: search was closed"))
+	}
+}
+
+// target returns the goal of the current segment.
+func (s *Search) target() objective {
+	if s.segment < len(s.options.waypoints) {
+		return goalOf(s.options.waypoints[s.segment])
+	}
+	return s.goal
+}
+
+// begin starts a new segment of the search.
+func (s *Search) begin() {
+	s.heap = acquireHeap(&s.options)
+	s.heap.prepare(s.actions, s.target().state)
+	s.pruned = false
+	s.invalid = false
+	s.touched = 0
+	s.used = s.used[:0]
+	for _, limit := range s.options.caps {
+		s.used = append(s.used, spentBy(s.dst, limit.Name))
+	}
+	s.odds = SuccessOf(s.dst)
+
+	start := s.current.Clone()
+	start.node = node{
+		heuristic: s.distance(s.target(), start),
+		stable:    s.follows(0, s.dst...),
+	}
+	start.included = s.performed(s.dst)
+	start.key = s.keyOf(start, nil, nil)
+	s.stats.Clone++
+	s.heap.Push(start)
+	s.trace.heap("push", start)
+	s.root = start
+
+	// The start of the segment is no longer needed if we own it
+	if s.current != s.origin {
+		s.current.release()
+	}
+	s.current = nil
+}
+
+// reach completes the current segment, having reached the specified node.
+func (s *Search) reach(node *State) {
+	if s.options.record {
+		s.steps = reconstructSteps(s.steps, node)
+	}
+
+	s.dst = reconstructPlan(s.dst, node)
+	s.cost += node.stateCost
+	s.current = node.Clone()
+	s.heap.Release()
+	s.heap, s.root, s.best = nil, nil, nil
+
+	// Proceed to the next segment, if any
+	s.segment++
+	if s.segment > len(s.options.waypoints) {
+		s.final, s.current = s.current, nil
+		if s.options.final != nil {
+			s.options.final.copyFrom(s.final)
+		}
+		s.finish(nil)
+	}
+}
+
+// finish completes the search with an optional error.
+func (s *Search) finish(err error) {
+	if s.heap != nil {
+		s.heap.Release()
+		s.heap, s.root, s.best = nil, nil, nil
+	}
+
+	if s.current != nil && s.current != s.origin {
+		s.current.release()
+	}
+
+	if terr := s.trace.flush(); err == nil && terr != nil {
+		err = fmt.Errorf("plan: unable to write trace: %w", terr)
+	}
+
+	s.current = nil
+	s.done = true
+	s.err = err
+	if err != nil {
+		s.dst = nil
+		s.steps = nil
+	}
+
+	if s.options.counters != nil {
+		*s.options.counters = s.stats
+	}
+
+	if err == nil {
+		s.options.analytics.record(s.dst)
+	}
+	s.logFinish(err)
+}
+
+// recover recovers from a panic during the search and converts it into an error
+// which carries the action and the state that were being evaluated.
+func (s *Search) recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	e := &PanicError{
+		Value: r,
+		Stack: debug.Stack(),
+	}
+
+	if s.heap != nil {
+		e.Action = s.heap.action
+		if s.heap.current != nil {
+			e.State = s.heap.current.String()
+		}
+	}
+
+	s.finish(e)
+}
+
+// expand expands a single node of the search graph.
+func (s *Search) expand() {
+	if s.heap == nil {
+		s.begin()
+	}
+
+	heap, goal, stats := s.heap, s.target(), &s.stats
+	if heap.Len() == 0 {
+		s.drained = true
+		s.finish(s.failure())
+		return
+	}
+
+	current, _ := heap.Pop()
+	heap.current = current
+	heap.action = nil
+	s.trace.heap("pop", current)
+
+	if current.depth >= maxDepth {
+		s.reach(current)
+		return
+	}
+
+	// If we reached the goal, reconstruct the path.
+	done, err := goal.Match(current)
+	stats.Match++
+	s.options.heatmap.read(goal.state)
+	switch {
+	case err != nil:
+		s.finish(err)
+		return
+	case done && s.complete(current):
+		s.reach(current)
+		return
+	case done: // The goal is reached without performing every required action
+		s.invalid = true
+	}
+
+	stats.Expand++
+	s.logExpand(current)
+	s.closer(current)
+	branch := stats.Branch
+	expanding := s.trace.now()
+	s.options.deadends.begin()
+
+	for i, action := range s.actions {
+		heap.action = action
+		began := s.trace.now()
+		require, outcome := heap.simulate(i, action, current)
+		s.touch(goal.state, outcome)
+		s.trace.simulate(began, action)
+		match, err := current.Match(require)
+		stats.Match++
+		s.options.heatmap.read(require)
+		if err == nil && match {
+			match, err = available(current, action)
+		}
+
+		switch {
+		case err != nil:
+			s.finish(err)
+			return
+		case !match:
+			s.options.deadends.block(current, require)
+			continue // Skip this action
+		}
+
+		stats.Branch++
+
+		// Prune the branches which exceed the total cost budget, the actions following the
+		// previous plan are discounted so that it is preferred
+		cost, extra, err := s.costOf(current, action)
+		if err != nil {
+			s.finish(err)
+			return
+		}
+
+		if s.factors != nil {
+			cost *= s.factors[i]
+		}
+
+		if s.options.jitter > 0 {
+			cost += cost * s.options.jitter * s.noise(current, i)
+		}
+
+		stable := current.stable && s.follows(len(s.dst)+current.depth, action)
+		if stable {
+			cost -= cost * s.options.discount
+		}
+
+		newCost := current.stateCost + cost
+		if s.options.budget > 0 && newCost > s.options.budget {
+			s.pruned = true
+			continue
+		}
+
+		// Prune the branches which exceed any of the resource caps or the risk bound
+		if !s.affords(current, action) || !s.safe(current, action) {
+			s.invalid = true
+			continue
+		}
+
+		// Apply the outcome to the new state
+		newState := current.Clone()
+		if err := s.transition(newState, action, outcome); err != nil {
+			newState.release()
+			s.finish(&ActionError{Action: nameOf(action), Err: fmt.Errorf("plan: action '%s': %w", nameOf(action), err)})
+			return
+		}
+
+		stats.Clone++
+		stats.Apply++
+		stats.Rehash += uint64(outcome.Len())
+		s.options.heatmap.write(outcome)
+
+		// Prune the branches which violate the constraints of the plan
+		if valid, err := s.valid(newState); !valid || err != nil {
+			newState.release()
+			if err != nil {
+				s.finish(err)
+				return
+			}
+			continue
+		}
+
+		// Check if newState is already planned to be visited or if the newCost is lower. The
+		// heuristic is memoized by the hash of the state, so it's only evaluated once per state.
+		if s.include != nil {
+			newState.included = current.included | s.include[i]
+		}
+
+		key := s.keyOf(newState, current, action)
+		node, found := heap.Find(key)
+		switch {
+		case !found && heap.Seen(key): // Only tracked approximately, so it may be a new state
+			newState.release()
+		case !found:
+			newState.key = key
+			heuristic := s.distance(goal, newState)
+			newState.parent = current
+			newState.action = action
+			newState.heuristic = heuristic
+			newState.stateCost = newCost
+			newState.totalCost = newCost + s.options.weight*heuristic
+			newState.depth = current.depth + 1
+			newState.stable = stable
+			newState.extra = current.extra.add(extra)
+			newState.tie = s.tie(newState)
+			heap.Push(newState)
+			s.trace.heap("push", newState)
+			if s.options.openLimit > 0 && heap.Len() > s.options.openLimit {
+				s.trace.heap("drop", heap.Remove(heap.victim(s.options.pruning)))
+			}
+			if len(s.options.breaks) > 0 {
+				switch resume, err := s.pause(action, newState); {
+				case err != nil:
+					s.finish(err)
+					return
+				case !resume:
+					s.finish(ErrStopped)
+					return
+				}
+			}
+
+		// In any of those cases, we need to release the new state
+		case found && !node.visited && s.better(newCost, current.extra.add(extra), node):
+			node.parent = current
+			node.action = action
+			node.depth = current.depth + 1
+			node.stable = stable
+			node.extra = current.extra.add(extra)
+			node.stateCost = newCost
+			node.totalCost = newCost + s.options.weight*node.heuristic
+			heap.Fix(node) // Update the node's position in the heap
+			s.trace.heap("fix", node)
+			fallthrough
+		default: // The new state is already visited or the newCost is higher
+			newState.release()
+		}
+	}
+
+	// No action is applicable from this node
+	if stats.Branch == branch {
+		stats.DeadEnd++
+	}
+	s.options.deadends.end(stats.Branch == branch)
+
+	s.trace.expand(expanding, current)
+}
+
+// costOf returns the cost of the action and, for the lexicographic costs, its secondary
+// costs. Multi-cost actions are combined according to the options of the search, while the
+// cost of the other ones may be estimated by the learned model in the state.
+func (s *Search) costOf(state *State, action Action) (cost float32, extra costs, err error) {
+	if s.options.weights == nil && !s.options.lexical {
+		return learnedCost(&s.options, state, action), extra, nil
+	}
+
+	multi, ok := action.(MultiCostAction)
+	if !ok {
+		if len(s.options.weights) > 0 {
+			return learnedCost(&s.options, state, action) * s.options.weights[0], extra, nil
+		}
+		return learnedCost(&s.options, state, action), extra, nil
+	}
+
+	vector := multi.Costs()
+	switch {
+	case len(vector) > MaxCosts:
+		return 0, extra, &ActionError{Action: nameOf(action), Err: fmt.Errorf("%w, action '%s' has %d costs", ErrTooManyCosts, nameOf(action), len(vector))}
+	case len(vector) == 0:
+		return 0, extra, nil
+	case s.options.lexical:
+		copy(extra[:], vector[1:])
+		return vector[0], extra, nil
+	}
+
+	for i, c := range vector {
+		if i < len(s.options.weights) {
+			cost += c * s.options.weights[i]
+		}
+	}
+	return cost, extra, nil
+}
+
+// distance returns the estimated distance from the state to the goal, memoized by the hash
+// of the state so that it's evaluated once per distinct state of the segment, even when
+// reached again after being dropped, or with different resources spent or actions performed.
+func (s *Search) distance(goal objective, state *State) float32 {
+	hash := state.Hash()
+	if h, ok := s.heap.dist[hash]; ok {
+		return h
+	}
+
+	h := learnedDistance(s.options.model, goal, state)
+	s.heap.dist[hash] = h
+	s.stats.Distance++
+	return h
+}
+
+// better returns whether the cost is lower than the one of the node.
+func (s *Search) better(cost float32, extra costs, node *State) bool {
+	if cost != node.stateCost {
+		return cost < node.stateCost
+	}
+	return extra.less(node.extra)
+}
+
+// affords returns whether performing the action after the node stays within the caps
+// of the resources, if any.
+func (s *Search) affords(node *State, action Action) bool {
+	for i, limit := range s.options.caps {
+		if amount := consumed(action, limit.Name); amount > 0 && s.used[i]+spent(node, limit.Name)+amount > limit.Amount {
+			return false
+		}
+	}
+	return true
+}
+
+// safe returns whether performing the action after the node keeps the probability of
+// the plan succeeding above the minimum, if any.
+func (s *Search) safe(node *State, action Action) bool {
+	if s.options.success <= 0 {
+		return true
+	}
+
+	risk := riskOf(action)
+	return risk == 0 || s.odds*successOf(node)*(1-risk) >= s.options.success
+}
+
+// tie returns the pseudo-random priority of the state used to break the ties between
+// equal costs, derived from the seed and the hash of the state.
+func (s *Search) tie(state *State) uint32 {
+	if s.options.seed == 0 {
+		return 0
+	}
+
+	return uint32(mix(s.options.seed ^ uint64(state.Hash())))
+}
+
+// noise returns the pseudo-random noise of the i-th action expanded from the state,
+// between 0 and 1, derived from the seed.
+func (s *Search) noise(state *State, i int) float32 {
+	x := mix(s.options.seed ^ uint64(state.Hash())<<32 ^ uint64(i))
+	return float32(x>>40) / (1 << 24)
+}
+
+// mix mixes the bits of the value using the splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// follows returns whether the actions match the previous plan at the specified offset.
+func (s *Search) follows(offset int, actions ...Action) bool {
+	if len(s.options.previous) < offset+len(actions) {
+		return false
+	}
+
+	for i, action := range actions {
+		if s.options.previous[offset+i] != action {
+			return false
+		}
+	}
+	return true
+}
+
+// transition applies the outcome of the action to the state and advances its time.
+func (s *Search) transition(state *State, action Action, outcome *State) error {
+	if err := state.apply(outcome, s.options.overflow, s.options.stance); err != nil {
+		return err
+	}
+	return advance(state, action, s.options.overflow)
+}
+
+// valid returns whether the state satisfies the invariant of the plan, if any, and
+// does not match any of the forbidden states.
+func (s *Search) valid(state *State) (bool, error) {
+	if s.options.invariant != nil {
+		s.stats.Match++
+		if ok, err := state.Match(s.options.invariant); !ok || err != nil {
+			s.invalid = s.invalid || err == nil
+			return false, err
+		}
+	}
+
+	for _, forbidden := range s.options.forbidden {
+		s.stats.Match++
+		if match, err := state.Match(forbidden); match || err != nil {
+			s.invalid = s.invalid || err == nil
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// failure returns the error explaining why no plan could be found.
+func (s *Search) failure() error {
+	switch {
+	case s.pruned:
+		return fmt.Errorf("%w, no plan could be found within the cost of %v", ErrBudget, s.options.budget)
+	case s.invalid:
+		return fmt.Errorf("%w without violating the constraints", ErrNoPlan)
+	}
+
+	// Most of the time, no plan is found because some of the goal facts cannot be
+	// influenced by any action, so we report them explicitly.
+	goal := s.target()
+	if goal.state == nil {
+		return ErrNoPlan
+	}
+
+	if facts := s.uncovered(goal.state); len(facts) > 0 {
+		return fmt.Errorf("%w, facts %s cannot be influenced by any action", ErrNoPlan, strings.Join(facts, ", "))
+	}
+
+	return ErrNoPlan
+}
+
+// touch records the facts of the goal, up to the first 64, written by the outcome.
+func (s *Search) touch(goal, outcome *State) {
+	if goal == nil {
+		return
+	}
+
+	goals := goal.vx[:min(len(goal.vx), 64)]
+	if s.touched == 1<<len(goals)-1 {
+		return // Every fact of the goal is already written
+	}
+
+	for _, r := range outcome.vx {
+		for i, g := range goals {
+			if g.Fact() == r.Fact() {
+				s.touched |= 1 << i
+			}
+		}
+	}
+}
+
+// uncovered returns the facts of the goal which none of the outcomes simulated during the
+// segment wrote, ignoring the ones already satisfied by its start.
+func (s *Search) uncovered(goal *State) (out []string) {
+	for i, r := range goal.vx[:min(len(goal.vx), 64)] {
+		if s.touched&(1<<i) != 0 {
+			continue
+		}
+
+		single := StateOfRules(r)
+		ok, err := s.root.Match(single)
+		single.release()
+		if err != nil || !ok {
+			out = append(out, r.Fact().String())
+		}
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchStep(t *testing.T) {
+	planner, err := NewPlanner([]Action{
+		actionOf("Eat", 1.0, StateOf("food>0"), StateOf("hunger-50", "food-5")),
+		actionOf("Forage", 1.0, StateOf("tired<50"), StateOf("tired+20", "food+10", "hunger+5")),
+		actionOf("Sleep", 1.0, StateOf("tired>30"), StateOf("tired-50")),
+	})
+	assert.NoError(t, err)
+
+	start, goal := StateOf("hunger=80", "!food", "!tired"), StateOf("food>80")
+	expect, err := planner.Plan(start, goal)
+	assert.NoError(t, err)
+
+	// The start state is copied, so modifying it does not affect the search
+	origin := start.Clone()
+	search := planner.Start(origin, goal)
+	assert.NoError(t, origin.Add("food=90"))
+	_, err = search.Result()
+	assert.Error(t, err)
+
+	frames := 0
+	for !search.Step(2) {
+		frames++
+	}
+
+	assert.Greater(t, frames, 1)
+	assert.True(t, search.Done())
+	assert.True(t, search.Step(1))
+
+	plan, err := search.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, planOf(expect), planOf(plan))
+}
+
+func TestSearchClose(t *testing.T) {
+	planner, err := NewPlanner([]Action{move("A->B"), move("B->C")})
+	assert.NoError(t, err)
+
+	search := planner.Start(StateOf("A"), StateOf("C"))
+	assert.False(t, search.Step(1))
+	search.Close()
+
+	assert.True(t, search.Done())
+	_, err = search.Result()
+	assert.Error(t, err)
+}
+
+func TestSearchNoPlan(t *testing.T) {
+	planner, err := NewPlanner([]Action{move("A->C"), move("B->C")})
+	assert.NoError(t, err)
+
+	search := planner.Start(StateOf("A", "B"), StateOf("C", "D"))
+	for !search.Step(1) {
+	}
+
+	_, err = search.Result()
+	assert.ErrorContains(t, err, "facts D cannot be influenced")
+}
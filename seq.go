@@ -0,0 +1,88 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+//go:build go1.23
+
+package goap
+
+import (
+	"iter"
+	"math"
+)
+
+// PlanSeq finds a plan to reach the goal from the start state using the provided actions
+// and returns an iterator over its actions, which is empty if no plan could be found. The
+// search only runs once the iteration starts, and the plan is consumed lazily.
+func PlanSeq(start, goal *State, actions []Action, opts ...Option) iter.Seq[Action] {
+	return func(yield func(Action) bool) {
+		var search Search
+		search.init(nil, start, goalOf(goal), actions, optionsOf(opts))
+		search.All()(yield)
+	}
+}
+
+// PlanSeq finds a plan to reach the goal from the start state and returns an iterator
+// over its actions, which is empty if no plan could be found.
+func (p *Planner) PlanSeq(start, goal *State, opts ...Option) iter.Seq[Action] {
+	return func(yield func(Action) bool) {
+		p.Start(start, goal, opts...).All()(yield)
+	}
+}
+
+// All returns an iterator over the actions of the plan found by the search, which runs
+// the search to completion first if needed. The iterator is empty if no plan was found,
+// in which case the error is returned by Result().
+func (s *Search) All() iter.Seq[Action] {
+	return func(yield func(Action) bool) {
+		for !s.Step(math.MaxInt) {
+		}
+
+		if s.final != nil {
+			s.final.release()
+			s.final = nil
+		}
+
+		plan, _ := s.Result()
+		for _, action := range plan {
+			if !yield(action) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the actions reaching the goal, planned from the sensed
+// state once the iteration starts, so that the plan executor can be driven from a range
+// loop. The caller performs every action as it is yielded and stops the iteration if one
+// fails, the retry policy only applying to Run. If the executor is reloaded, the rest of
+// the plan is abandoned and a new plan is found from the sensed state. If no plan can be
+// found, the error is yielded along with a nil action and the iteration stops.
+func (e *Executor) All(sense func() *State, goal *State) iter.Seq2[Action, error] {
+	return func(yield func(Action, error) bool) {
+		for {
+			planner, target, version := e.domain(goal)
+			plan, err := planner.Plan(sense(), target)
+			if err != nil {
+				e.emit(Event{Kind: EventGoalAbandoned, Goal: target, Err: err})
+				yield(nil, err)
+				return
+			}
+
+			e.emit(Event{Kind: EventPlanFound, Goal: target, Plan: plan})
+			for _, action := range plan {
+				if e.Version() != version {
+					break // The plan is stale
+				}
+
+				if !yield(action, nil) {
+					return
+				}
+			}
+
+			if e.Version() == version {
+				e.emit(Event{Kind: EventGoalReached, Goal: target})
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+//go:build go1.23
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSeq(t *testing.T) {
+	actions := []Action{move("A->B"), move("B->C"), move("C->D")}
+
+	var names []string
+	for action := range PlanSeq(StateOf("A"), StateOf("D"), actions) {
+		names = append(names, action.(*testAction).name)
+	}
+	assert.Equal(t, []string{"A->B", "B->C", "C->D"}, names)
+
+	// The iteration can be stopped early
+	planner, err := NewPlanner(actions)
+	assert.NoError(t, err)
+
+	names = names[:0]
+	for action := range planner.PlanSeq(StateOf("A"), StateOf("D")) {
+		names = append(names, action.(*testAction).name)
+		break
+	}
+	assert.Equal(t, []string{"A->B"}, names)
+
+	// No plan results in an empty sequence, and the error is kept by the search
+	search := planner.Start(StateOf("A"), StateOf("Z"))
+	for range search.All() {
+		assert.Fail(t, "unexpected action")
+	}
+
+	_, err = search.Result()
+	assert.Error(t, err)
+}
+
+func TestExecutorAll(t *testing.T) {
+	planner, err := NewPlanner([]Action{move("A->B"), move("B->C"), move("B->D")})
+	assert.NoError(t, err)
+
+	// The goal changes while the agent is on its way
+	world := StateOf("A")
+	executor := NewExecutor(planner, RetryPolicy{})
+	var performed []string
+	for action, err := range executor.All(func() *State { return world.Clone() }, StateOf("C")) {
+		assert.NoError(t, err)
+		performed = append(performed, action.(*testAction).name)
+		if len(performed) == 1 {
+			executor.Reload(nil, StateOf("D"))
+		}
+
+		_, outcome := action.Simulate(world)
+		assert.NoError(t, world.Apply(outcome))
+	}
+	assert.Equal(t, []string{"A->B", "B->D"}, performed)
+
+	// The error is yielded if no plan can be found
+	executor = NewExecutor(planner, RetryPolicy{})
+	for action, err := range executor.All(func() *State { return StateOf("A") }, StateOf("Z")) {
+		assert.Nil(t, action)
+		assert.ErrorIs(t, err, ErrNoPlan)
+	}
+}
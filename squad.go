@@ -0,0 +1,174 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Merge represents how the values of a shared fact reported by the members of a squad are
+// combined into the single value every member plans with.
+type Merge uint8
+
+const (
+	MergeLatest Merge = iota // The value reported last by the current members wins (default)
+	MergeMax                 // The largest of the values currently reported wins
+	MergeAny                 // The fact is true as long as any member reports it true
+)
+
+// Squad shares some of the facts between the states of its members, so that one guard
+// spotting the player updates the state every member of the squad plans with. Only the
+// facts marked with Share() are shared, the other facts remain private to each member.
+// The reports are merged under the lock of the squad, so all the members observe the
+// same sequence of merged values.
+type Squad struct {
+	lock    sync.Mutex
+	members []*SyncState
+	merge   map[fact]Merge
+	reports map[fact]map[*SyncState]report
+	seq     uint64 // The sequence number of the last report
+}
+
+// report represents the value of a shared fact reported by a member.
+type report struct {
+	value expr   // The value reported
+	seq   uint64 // The order of the report, the latest being the largest
+}
+
+// NewSquad creates a new squad without any member or shared fact.
+func NewSquad() *Squad {
+	return &Squad{
+		merge:   make(map[fact]Merge),
+		reports: make(map[fact]map[*SyncState]report),
+	}
+}
+
+// Share marks the fact as shared between the members of the squad, combining the values
+// they report with the merge rule.
+func (s *Squad) Share(name string, merge Merge) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f := factOf(name)
+	s.merge[f] = merge
+	if _, ok := s.reports[f]; !ok {
+		s.reports[f] = make(map[*SyncState]report)
+	}
+}
+
+// Join adds the state to the squad, overwriting its shared facts with the merged values
+// reported by the other members so far.
+func (s *Squad) Join(member *SyncState) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, m := range s.members {
+		if m == member {
+			return nil
+		}
+	}
+
+	s.members = append(s.members, member)
+	for f := range s.merge {
+		if e, ok := s.merged(f); ok {
+			if err := member.AddRule(ruleOf(f, e)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Leave removes the state from the squad, withdrawing its reports. The shared facts of the
+// remaining members are updated accordingly, while the state keeps its current values.
+func (s *Squad) Leave(member *SyncState) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, m := range s.members {
+		if m == member {
+			s.members = append(s.members[:i], s.members[i+1:]...)
+			break
+		}
+	}
+
+	for f, reports := range s.reports {
+		if _, ok := reports[member]; ok {
+			delete(reports, member)
+			if err := s.publish(f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Report reports the rule observed by the member, such as "player" once a guard spots
+// the player. If the fact is shared, the merged value is written to the state of every
+// member of the squad, otherwise the rule is only added to the state of the member.
+func (s *Squad) Report(member *SyncState, rule string) error {
+	f, e, err := parseRule(rule)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	reports, shared := s.reports[f]
+	switch {
+	case !shared:
+		return member.AddRule(ruleOf(f, e))
+	case e.Operator() != opEqual:
+		return factError(ruleOf(f, e), fmt.Errorf("plan: shared fact '%s' must be reported with a value, got '%s'", f, ruleOf(f, e)))
+	}
+
+	s.seq++
+	reports[member] = report{value: e, seq: s.seq}
+	return s.publish(f)
+}
+
+// publish writes the merged value of the shared fact to the state of every member.
+func (s *Squad) publish(f fact) error {
+	e, ok := s.merged(f)
+	if !ok {
+		return nil
+	}
+
+	for _, m := range s.members {
+		if err := m.AddRule(ruleOf(f, e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merged returns the merged value of the shared fact, and whether any value was reported.
+func (s *Squad) merged(f fact) (expr, bool) {
+	reports := s.reports[f]
+	if len(reports) == 0 {
+		return 0, false
+	}
+
+	switch s.merge[f] {
+	case MergeMax:
+		var value int32
+		for _, r := range reports {
+			value = max(value, r.value.Fixed())
+		}
+		return exprOfFixed(opEqual, value), true
+	case MergeAny:
+		for _, r := range reports {
+			if r.value.Fixed() > 0 {
+				return exprOf(opEqual, MaxValue), true
+			}
+		}
+		return exprOfFixed(opEqual, 0), true
+	default:
+		var latest report
+		for _, r := range reports {
+			if r.seq > latest.seq {
+				latest = r
+			}
+		}
+		return latest.value, true
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSquad(t *testing.T) {
+	guard1 := NewSyncState("!player", "alarm=0", "ammo=50")
+	guard2 := NewSyncState("!player", "alarm=0", "ammo=80")
+	guard3 := NewSyncState("!player", "alarm=0")
+
+	squad := NewSquad()
+	squad.Share("player", MergeAny)
+	squad.Share("alarm", MergeMax)
+	squad.Share("noise", MergeLatest)
+	assert.NoError(t, squad.Join(guard1))
+	assert.NoError(t, squad.Join(guard2))
+
+	// One guard spotting the player updates the whole squad
+	assert.NoError(t, squad.Report(guard1, "player"))
+	assertMatch(t, true, "player", guard1, guard2)
+
+	// The player remains spotted as long as any of the guards sees it
+	assert.NoError(t, squad.Report(guard2, "!player"))
+	assertMatch(t, true, "player", guard1, guard2)
+	assert.NoError(t, squad.Report(guard1, "!player"))
+	assertMatch(t, false, "player", guard1, guard2)
+
+	// The highest alarm level is shared
+	assert.NoError(t, squad.Report(guard1, "alarm=30"))
+	assert.NoError(t, squad.Report(guard2, "alarm=20"))
+	assertMatch(t, true, "alarm=30", guard1, guard2)
+
+	// The latest report wins
+	assert.NoError(t, squad.Report(guard2, "noise=10"))
+	assert.NoError(t, squad.Report(guard1, "noise=5"))
+	assertMatch(t, true, "noise=5", guard1, guard2)
+
+	// The private facts are not shared
+	assert.NoError(t, squad.Report(guard1, "ammo=10"))
+	assertMatch(t, true, "ammo=10", guard1)
+	assertMatch(t, true, "ammo=80", guard2)
+
+	// A new member catches up with the squad
+	assert.NoError(t, squad.Join(guard3))
+	assertMatch(t, true, "alarm=30", guard3)
+	assertMatch(t, true, "noise=5", guard3)
+
+	// Leaving withdraws the reports of the member
+	assert.NoError(t, squad.Leave(guard1))
+	assertMatch(t, true, "alarm=20", guard2, guard3)
+	assertMatch(t, true, "alarm=30", guard1)
+	assertMatch(t, true, "noise=10", guard2, guard3)
+	assertMatch(t, true, "noise=5", guard1)
+}
+
+func TestSquadErrors(t *testing.T) {
+	state := NewSyncState()
+	squad := NewSquad()
+	squad.Share("alarm", MergeMax)
+	assert.NoError(t, squad.Join(state))
+	assert.NoError(t, squad.Join(state))
+
+	assert.Error(t, squad.Report(state, ""))
+	assert.Error(t, squad.Report(state, "alarm+10"))
+	assert.NoError(t, squad.Report(state, "alarm=10"))
+	assertMatch(t, true, "alarm=10", state)
+}
+
+func assertMatch(t *testing.T, expect bool, rule string, states ...*SyncState) {
+	for _, state := range states {
+		ok, err := state.Match(StateOf(rule))
+		assert.NoError(t, err)
+		assert.Equal(t, expect, ok, state.String())
+	}
+}
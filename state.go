@@ -4,27 +4,30 @@
 package goap
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"sync"
 )
 
-const linearCutoff = 16 // 2 cache line
+const (
+	linearCutoff = 16 // 2 cache line
+	inlineSize   = 8  // 1 cache line
+)
 
 var pool = sync.Pool{
 	New: func() any {
-		return &State{
-			hx: 0,
-			vx: make([]rule, 0, 16),
-		}
+		state := new(State)
+		state.vx = state.ix[:0]
+		return state
 	},
 }
 
 func newState(capacity int) *State {
 	state := pool.Get().(*State)
 	if cap(state.vx) < capacity {
-		state.vx = make([]rule, 0, capacity)
+		state.vx = make([]Rule, 0, capacity)
 	}
 	return state
 }
@@ -33,8 +36,11 @@ func newState(capacity int) *State {
 
 // State represents a state of the world.
 type State struct {
-	hx uint32 // Hash of the state
-	vx []rule // Keys and values, interleaved
+	hx   uint32           // Hash of the state, including the base state
+	vx   []Rule           // Keys and values, interleaved
+	ix   [inlineSize]Rule // Inline storage for small states, vx points here
+	base *State           // Optional read-only base state, shadowed by vx
+	log  *history         // Optional history of the applied effects, nil if disabled
 	node
 }
 
@@ -47,13 +53,70 @@ type node struct {
 	index     int     // Index of the state in the heap
 	depth     int     // Depth of the state in the tree
 	visited   bool    // Whether the state was visited
+	stable    bool    // Whether the path to the state follows the previous plan
+	extra     costs   // Secondary costs from the start state, for lexicographic costs
+	tie       uint32  // Pseudo-random priority breaking the ties between equal costs
+	key       uint32  // Key of the state in the visited set of the search
+	seq       uint32  // Order in which the state was pushed into the heap
+	included  uint64  // Bits of the required actions performed since the start state
 }
 
-// StateOf creates a new state from a list of keys.
+// costs represents the secondary costs of a lexicographic cost vector.
+type costs [MaxCosts - 1]float32
+
+// less reports whether the costs are lexicographically lower than the other costs.
+func (c costs) less(other costs) bool {
+	for i := range c {
+		if c[i] != other[i] {
+			return c[i] < other[i]
+		}
+	}
+	return false
+}
+
+// add returns the sum of the costs.
+func (c costs) add(other costs) costs {
+	for i := range c {
+		c[i] += other[i]
+	}
+	return c
+}
+
+// StateOf creates a new state from a list of keys. It panics if any of the rules
+// are invalid, reporting all of the failures at once.
 func StateOf(rules ...string) *State {
+	state, err := TryStateOf(rules...)
+	if err != nil {
+		panic(err)
+	}
+	return state
+}
+
+// TryStateOf creates a new state from a list of keys. It returns an error reporting all
+// of the invalid rules at once, for example when the rules are loaded from a file.
+func TryStateOf(rules ...string) (*State, error) {
 	state := newState(len(rules))
+
+	var errs []error
 	for _, fact := range rules {
 		if err := state.Add(fact); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		state.release()
+		return nil, err
+	}
+	return state, nil
+}
+
+// StateOfRules creates a new state from a list of precompiled rules. It panics if the
+// number of rules exceeds the maximum number of facts in a state.
+func StateOfRules(rules ...Rule) *State {
+	state := newState(len(rules))
+	for _, rule := range rules {
+		if err := state.AddRule(rule); err != nil {
 			panic(err)
 		}
 	}
@@ -61,11 +124,19 @@ func StateOf(rules ...string) *State {
 }
 
 func (s *State) release() {
+	s.reset()
+	pool.Put(s)
+}
+
+// reset clears the state so it can be reused.
+func (s *State) reset() {
 	clear(s.vx)
 	s.hx = 0
 	s.vx = s.vx[:0]
+	s.ix = [inlineSize]Rule{}
+	s.base = nil
+	s.log = nil
 	s.node = node{}
-	pool.Put(s)
 }
 
 func (s *State) sort() {
@@ -93,10 +164,32 @@ func (s *State) find(key fact) (int, bool) {
 	return x, false
 }
 
-// Store stores a key in the state, note that it requires rehashing the state
-// and sorting the keys. This is NOT DONE by this method. The return value
-// indicates whether the key was added to the state (true) or updated (false).
-func (s *State) store(k fact, v expr) {
+// lookup returns the expression of the fact, falling through to the base state if the
+// fact is not present in the state itself.
+func (s *State) lookup(f fact) (expr, bool) {
+	if i, ok := s.find(f); ok {
+		return s.vx[i].Expr(), true
+	}
+	if s.base != nil {
+		return s.base.lookup(f)
+	}
+	return 0, false
+}
+
+// shadowed returns the hash of the rule of the base state shadowed by the fact, if any.
+func (s *State) shadowed(f fact) uint32 {
+	if s.base == nil {
+		return 0
+	}
+	if e, ok := s.base.lookup(f); ok {
+		return ruleOf(f, e).Hash()
+	}
+	return 0
+}
+
+// Store stores a key in the state, incrementally rehashing the state and keeping
+// the keys sorted. It fails if the state would exceed the maximum number of facts.
+func (s *State) store(k fact, v expr) error {
 	r := ruleOf(k, v)
 
 	// Check if the key already exists
@@ -104,13 +197,65 @@ func (s *State) store(k fact, v expr) {
 		s.hx ^= s.vx[i].Hash()
 		s.hx ^= r.Hash()
 		s.vx[i] = r
-		return
+		return nil
 	}
 
-	// If not, add it to the state
+	if len(s.vx) >= MaxFacts {
+		return factError(r, fmt.Errorf("%w, cannot add '%s'", ErrTooManyFacts, r))
+	}
+
+	// If not, add it to the state, shadowing the base state if needed
+	s.hx ^= s.shadowed(k)
 	s.hx ^= r.Hash()
-	s.vx = append(s.vx, ruleOf(k, v))
+	s.vx = append(s.vx, r)
 	s.sort()
+	return nil
+}
+
+// WithBase sets the read-only base state of the state, turning it into an overlay. The
+// lookups of the facts which are not present in the state fall through to the base, while
+// the writes only go to the state itself. This allows to plan over a large shared world
+// state without cloning it for every agent, as the clones of the state share its base.
+// The base must not be modified while in use, and Del() only removes from the overlay.
+func (s *State) WithBase(base *State) *State {
+	s.hx ^= s.baseHash()
+	s.base = base
+	s.hx ^= s.baseHash()
+	return s
+}
+
+// baseHash returns the hash of the base state, excluding the rules shadowed by the state.
+func (s *State) baseHash() uint32 {
+	if s.base == nil {
+		return 0
+	}
+
+	h := s.base.hx
+	for _, r := range s.vx {
+		h ^= s.shadowed(r.Fact())
+	}
+	return h
+}
+
+// storeFixed stores a fixed-point value of a fact, handling the values outside of the
+// range according to the overflow behavior.
+func (s *State) storeFixed(k fact, v int32, overflow Overflow) error {
+	lo, hi := MinValue*scale, MaxValue*scale
+	if v >= lo && v <= hi {
+		return s.store(k, exprOfFixed(opEqual, v))
+	}
+
+	switch overflow {
+	case OverflowWrap:
+		v = (v-lo)%(hi-lo) + lo
+		if v < lo {
+			v += hi - lo
+		}
+	case OverflowError:
+		return &FactError{Fact: k.String(), Err: fmt.Errorf("%w, '%s' would become %v", ErrOverflow, k, float64(v)/float64(scale))}
+	}
+
+	return s.store(k, exprOfFixed(opEqual, v))
 }
 
 // Add adds a key to the state.
@@ -120,8 +265,12 @@ func (s *State) Add(rule string) error {
 		return err
 	}
 
-	s.store(k, v)
-	return nil
+	return s.store(k, v)
+}
+
+// AddRule adds a precompiled rule to the state.
+func (s *State) AddRule(rule Rule) error {
+	return s.store(rule.Fact(), rule.Expr())
 }
 
 // Del removes a key from the state.
@@ -136,24 +285,29 @@ func (s *State) Del(rule string) error {
 		return nil
 	}
 
-	// If we deleted, we need to sort and rehash. The sorting will place
-	// the zero value at the end of the slice, so we can just trim it.
+	// Since the rules are kept sorted, we can simply shift the tail back by
+	// one element instead of re-sorting, leaving no holes behind.
 	s.hx ^= s.vx[i].Hash()
-	s.vx[i] = 0
-	s.sort()
+	s.hx ^= s.shadowed(k)
+	copy(s.vx[i:], s.vx[i+1:])
+	s.vx[len(s.vx)-1] = 0
 	s.vx = s.vx[:len(s.vx)-1]
 	return nil
 }
 
-func (s State) load(f fact) expr {
-	if i, ok := s.find(f); ok {
-		return s.vx[i].Expr()
+func (s *State) load(f fact) expr {
+	if e, ok := s.lookup(f); ok {
+		return e
 	}
 	return exprOf(opEqual, 0)
 }
 
 // Match checks if the State satisfies all the rules of the other state.
 func (state *State) Match(needs *State) (bool, error) {
+	if state.base != nil {
+		return state.matchOverlay(needs)
+	}
+
 	i, j := 0, 0
 	for i < len(needs.vx) && j < len(state.vx) {
 		f0 := needs.vx[i].Fact()
@@ -161,29 +315,9 @@ func (state *State) Match(needs *State) (bool, error) {
 
 		switch {
 		case f1 == f0:
-			e0 := needs.vx[i].Expr()
-			e1 := state.vx[j].Expr()
-
-			if e1.Operator() != opEqual {
-				return false, fmt.Errorf("plan: cannot match '%s%s', invalid state '%s'",
-					f1.String(), e0.String(), e1.String())
-			}
-
-			match := false
-			switch e0.Operator() {
-			case opEqual:
-				match = e1.Value() == e0.Value()
-			case opLess:
-				match = e1.Value() < e0.Value()
-			case opGreater:
-				match = e1.Value() > e0.Value()
-			default:
-				return false, fmt.Errorf("plan: cannot match '%s%s', invalid operator '%s'",
-					f1.String(), e0.String(), e0.Operator().String())
-			}
-
-			if !match {
-				return false, nil
+			match, err := satisfies(f1, needs.vx[i].Expr(), state.vx[j].Expr())
+			if !match || err != nil {
+				return false, err
 			}
 
 			j++
@@ -199,38 +333,142 @@ func (state *State) Match(needs *State) (bool, error) {
 	return i == len(needs.vx), nil
 }
 
-// Apply adds (applies) the keys from the effects to the state.
+// matchOverlay checks if the overlay state satisfies all the rules of the other state,
+// looking up every fact through the base state.
+func (state *State) matchOverlay(needs *State) (bool, error) {
+	for _, need := range needs.vx {
+		have, ok := state.lookup(need.Fact())
+		if !ok {
+			return false, nil
+		}
+
+		if match, err := satisfies(need.Fact(), need.Expr(), have); !match || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// satisfies checks if the value of the fact satisfies the expression it needs.
+func satisfies(f fact, e0, e1 expr) (bool, error) {
+	if e1.Operator() != opEqual {
+		return false, factError(ruleOf(f, e0), fmt.Errorf("plan: cannot match '%s%s', invalid state '%s'",
+			f.String(), e0.String(), e1.String()))
+	}
+
+	switch e0.Operator() {
+	case opEqual:
+		return e1.Fixed() == e0.Fixed(), nil
+	case opLess:
+		return e1.Fixed() < e0.Fixed(), nil
+	case opGreater:
+		return e1.Fixed() > e0.Fixed(), nil
+	case opBetween:
+		lo, hi, _ := e0.Band()
+		return e1.Fixed() > lo && e1.Fixed() < hi, nil
+	default:
+		return false, factError(ruleOf(f, e0), fmt.Errorf("plan: cannot match '%s%s', invalid operator '%s'",
+			f.String(), e0.String(), e0.Operator().String()))
+	}
+}
+
+// Apply adds (applies) the keys from the effects to the state. Values pushed outside
+// of their range by an effect saturate to the bounds of the range, and the uncertain
+// effects change the values by the middle of their range.
 func (s *State) Apply(effects *State) error {
+	return s.ApplyAction(nil, effects)
+}
+
+// ApplyAction applies the effects of the action to the state. It is equivalent to Apply,
+// except that the action is recorded as responsible for the change in the history of the
+// state, if enabled.
+func (s *State) ApplyAction(action Action, effects *State) error {
+	if err := s.apply(effects, OverflowSaturate, StanceExpected); err != nil {
+		return err
+	}
+
+	if s.log != nil {
+		s.log.record(s, action, effects)
+	}
+	return nil
+}
+
+// apply adds (applies) the keys from the effects to the state, handling the values
+// pushed outside of their range according to the overflow behavior and the uncertain
+// effects according to the stance.
+func (s *State) apply(effects *State, overflow Overflow, stance Stance) error {
 	for _, elem := range effects.vx {
 		f, e := elem.Fact(), elem.Expr()
 		x := s.load(f)
 
 		// Current state must be a full state
 		if x.Operator() != opEqual {
-			return fmt.Errorf("plan: cannot apply '%s%s', invalid state '%s'", f.String(), e.String(), x.String())
+			return factError(elem, fmt.Errorf("plan: cannot apply '%s%s', invalid state '%s'", f.String(), e.String(), x.String()))
 		}
 
 		// Apply the effect to the state
+		var err error
 		switch e.Operator() {
 		case opEqual:
-			s.store(f, e)
+			err = s.store(f, e)
 		case opIncrement:
-			s.store(f, exprOf(x.Operator(), x.Value()+e.Value()))
+			err = s.storeFixed(f, x.Fixed()+e.Fixed(), overflow)
 		case opDecrement:
-			s.store(f, exprOf(x.Operator(), x.Value()-e.Value()))
+			err = s.storeFixed(f, x.Fixed()-e.Fixed(), overflow)
+		case opIncrementRef, opDecrementRef:
+			ref, _ := e.Ref()
+			delta := s.load(ref).Fixed()
+			if e.Operator() == opDecrementRef {
+				delta = -delta
+			}
+			err = s.storeFixed(f, x.Fixed()+delta, overflow)
+		case opIncrementAny, opDecrementAny:
+			err = s.storeFixed(f, x.Fixed()+stance.delta(e), overflow)
 		default:
-			return fmt.Errorf("plan: cannot apply '%s%s', invalid predict operator '%s'", f.String(), e.String(), e.Operator().String())
+			return factError(elem, fmt.Errorf("plan: cannot apply '%s%s', invalid predict operator '%s'", f.String(), e.String(), e.Operator().String()))
+		}
+
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// delta returns the signed change assumed for the uncertain effect. The pessimistic stance
+// assumes the lower bound of an increase but the upper bound of a decrease.
+func (stance Stance) delta(e expr) int32 {
+	lo, hi, _ := e.Band()
+	if e.Operator() == opDecrementAny {
+		lo, hi = -hi, -lo
+	}
+
+	switch stance {
+	case StancePessimistic:
+		return lo
+	case StanceOptimistic:
+		return hi
+	default:
+		return lo + (hi-lo)/2
+	}
+}
+
 // Distance estimates the distance to the goal state.
 func (state *State) Distance(goal *State) (diff float32) {
+	if state.base != nil {
+		for _, g := range goal.vx {
+			v := float32(0)
+			if e, ok := state.lookup(g.Fact()); ok {
+				v = e.Value()
+			}
+			diff += gap(g.Expr(), v)
+		}
+		return diff
+	}
+
 	i := 0
 	for _, g := range goal.vx {
-		x := g.Expr().Value()
 		v := float32(0)
 
 		// Find the value in the state
@@ -244,30 +482,47 @@ func (state *State) Distance(goal *State) (diff float32) {
 			}
 		}
 
-		// Calculate the difference, normalized
-		switch g.Expr().Operator() {
-		case opEqual:
-			switch {
-			case v < x:
-				diff += (x - v)
-			case v > x:
-				diff += (v - x)
-			default: // v == x
-			}
+		diff += gap(g.Expr(), v)
+	}
 
-		case opLess:
-			if v > x {
-				diff += (v - x)
-			}
+	return diff
+}
 
-		case opGreater:
-			if v < x {
-				diff += (x - v)
-			}
+// gap returns the difference between the value and the expression of the goal.
+func gap(g expr, v float32) float32 {
+	x := g.Value()
+
+	// Calculate the difference, normalized
+	switch g.Operator() {
+	case opEqual:
+		switch {
+		case v < x:
+			return x - v
+		case v > x:
+			return v - x
+		}
+
+	case opLess:
+		if v > x {
+			return v - x
+		}
+
+	case opGreater:
+		if v < x {
+			return x - v
+		}
+
+	case opBetween:
+		lo, hi, _ := g.Band()
+		switch low, high := float32(lo)/float32(scale), float32(hi)/float32(scale); {
+		case v < low:
+			return low - v
+		case v > high:
+			return v - high
 		}
 	}
 
-	return diff
+	return 0
 }
 
 // Equals returns true if the state is equal to the other state.
@@ -280,25 +535,85 @@ func (s *State) Hash() (h uint32) {
 	return s.hx
 }
 
+// seededHash returns the hash of the state, including its base state, where every rule is
+// mixed with the seed so that the collisions differ from the ones of Hash().
+func (s *State) seededHash(seed uint64) (h uint32) {
+	if s.base != nil {
+		flat := s.Flatten()
+		defer flat.release()
+		return flat.seededHash(seed)
+	}
+
+	for _, r := range s.vx {
+		h ^= uint32(mix(seed ^ uint64(r)))
+	}
+	return h
+}
+
 // Clone returns a clone of the state.
 func (s *State) Clone() *State {
-	clone := newState(len(s.vx))
+	clone := pool.Get().(*State)
 	clone.hx = s.hx
+	clone.base = s.base
+
+	// Small states are kept inline, hence a plain copy of the array suffices. If
+	// the pooled state already owns a larger buffer, we keep it for reuse.
+	if len(s.vx) <= inlineSize && cap(clone.vx) <= inlineSize {
+		copy(clone.ix[:], s.vx)
+		clone.vx = clone.ix[:len(s.vx)]
+		return clone
+	}
+
+	if cap(clone.vx) < len(s.vx) {
+		clone.vx = make([]Rule, 0, len(s.vx))
+	}
 	clone.vx = clone.vx[:len(s.vx)]
 	copy(clone.vx, s.vx)
 	return clone
 }
 
-// String returns a string representation of the state.
+// copyFrom overwrites the rules and the base of the state with the ones of the source.
+func (s *State) copyFrom(src *State) {
+	clear(s.vx)
+	s.vx = append(s.vx[:0], src.vx...)
+	s.hx = src.hx
+	s.base = src.base
+}
+
+// String returns a string representation of the state, including its base state.
 func (s *State) String() string {
+	if s.base != nil {
+		flat := s.Flatten()
+		defer flat.release()
+		return flat.String()
+	}
+
 	values := make([]string, 0, len(s.vx))
 	for _, elem := range s.vx {
-		values = append(values, elem.Fact().String()+elem.Expr().String())
+		values = append(values, elem.String())
 	}
 
 	return "{" + strings.Join(values, ", ") + "}"
 }
 
+// Flatten returns a new state merging the state with its base state, if any.
+func (s *State) Flatten() *State {
+	if s.base == nil {
+		return s.Clone()
+	}
+
+	flat := s.base.Flatten()
+	for _, r := range s.vx {
+		flat.store(r.Fact(), r.Expr())
+	}
+	return flat
+}
+
+// Rules returns a copy of the rules of the state, excluding its base state.
+func (s *State) Rules() []Rule {
+	return append([]Rule(nil), s.vx...)
+}
+
 // Len returns the number of elements in the state.
 func (s *State) Len() int {
 	return len(s.vx)
@@ -4,6 +4,8 @@
 package goap
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -245,4 +247,209 @@ func TestApplyError(t *testing.T) {
 	state2 := StateOf("A")
 	assert.Error(t, state1.Apply(state2))
 	assert.Error(t, state2.Apply(state1))
+
+	// The failing rule is available as a structured context
+	var factErr *FactError
+	assert.True(t, errors.As(state2.Apply(state1), &factErr))
+	assert.Equal(t, "A", factErr.Fact)
+	assert.Equal(t, ">10", factErr.Expr)
+}
+
+func TestAddDelChurn(t *testing.T) {
+	state := StateOf()
+	names := make([]string, 64)
+	for i := range names {
+		names[i] = fmt.Sprintf("fact%d", i)
+	}
+
+	for i := 0; i < 5000; i++ {
+		assert.NoError(t, state.Add(names[i%len(names)]))
+		if i%3 == 0 {
+			assert.NoError(t, state.Del(names[(i*7)%len(names)]))
+		}
+	}
+
+	// Rebuild the same state from scratch and compare
+	expect := StateOf()
+	for i := 0; i < state.Len(); i++ {
+		r := state.vx[i]
+		expect.store(r.Fact(), r.Expr())
+	}
+
+	assert.Equal(t, expect.Hash(), state.Hash())
+	for i := 1; i < state.Len(); i++ {
+		assert.Greater(t, state.vx[i-1].Fact(), state.vx[i].Fact())
+	}
+
+	for _, name := range names {
+		assert.NoError(t, state.Del(name))
+	}
+	assert.Equal(t, 0, state.Len())
+	assert.Equal(t, uint32(0), state.Hash())
+}
+
+func TestCloneInline(t *testing.T) {
+	state := StateOf("A", "B", "C")
+	state.Clone().release()
+	assert.Equal(t, float64(0), testing.AllocsPerRun(100, func() {
+		state.Clone().release()
+	}))
+
+	// Spill beyond the inline capacity
+	large := StateOf("A", "B", "C", "D", "E", "F", "G", "H", "I", "J")
+	clone := large.Clone()
+	assert.True(t, clone.Equals(large))
+	assert.Equal(t, large.String(), clone.String())
+
+	// Make sure the clone does not share storage with the original
+	small := state.Clone()
+	assert.NoError(t, small.Add("D"))
+	assert.Equal(t, "{C=100, B=100, A=100}", state.String())
+	assert.Equal(t, "{D=100, C=100, B=100, A=100}", small.String())
+}
+
+func TestStateOfRules(t *testing.T) {
+	a, _ := CompileRule("A")
+	b, _ := CompileRule("B=50")
+	state := StateOfRules(a, b)
+	assert.True(t, state.Equals(StateOf("A", "B=50")))
+
+	c, _ := CompileRule("C")
+	state.AddRule(c)
+	assert.True(t, state.Equals(StateOf("A", "B=50", "C")))
+	assert.Equal(t, float64(0), testing.AllocsPerRun(100, func() {
+		state.AddRule(c)
+	}))
+}
+
+func TestApplyFixedPoint(t *testing.T) {
+	tests := []struct {
+		start, effect string
+		times         int
+		expect        string
+	}{
+		{"A=100", "A-10", 10, "{A=0}"},
+		{"A=0", "A+0.1", 1000, "{A=100}"},
+		{"A=100", "A-0.01", 10000, "{A=0}"},
+		{"A=0", "A+0.3", 10, "{A=3}"},
+		{"A=50", "A-0.07", 100, "{A=43}"},
+	}
+
+	for _, test := range tests {
+		state := StateOf(test.start)
+		effect := StateOf(test.effect)
+		for i := 0; i < test.times; i++ {
+			assert.NoError(t, state.Apply(effect))
+		}
+
+		assert.Equal(t, test.expect, state.String(), test.effect)
+		assert.True(t, state.Equals(StateOf(test.expect[1:len(test.expect)-1])))
+	}
+}
+
+func TestTooManyFacts(t *testing.T) {
+	state := StateOf()
+	for i := 0; i < MaxFacts; i++ {
+		assert.NoError(t, state.Add(fmt.Sprintf("f%d", i)))
+	}
+
+	// Updating an existing fact is still allowed
+	assert.NoError(t, state.Add("f0=50"))
+	assert.ErrorIs(t, state.Add("overflow"), ErrTooManyFacts)
+	assert.ErrorIs(t, state.Apply(StateOf("overflow")), ErrTooManyFacts)
+	assert.Equal(t, MaxFacts, state.Len())
+}
+
+func TestApplyOverflow(t *testing.T) {
+	tests := []struct {
+		start, effect string
+		overflow      Overflow
+		expect        string
+	}{
+		{"A=90", "A+20", OverflowSaturate, "{A=100}"},
+		{"A=10", "A-20", OverflowSaturate, "{A=0}"},
+		{"A=90", "A+20", OverflowWrap, "{A=10}"},
+		{"A=10", "A-20", OverflowWrap, "{A=90}"},
+		{"A=90", "A+10", OverflowWrap, "{A=100}"},
+		{"A=90", "A+20", OverflowError, "(error)"},
+		{"A=10", "A-20", OverflowError, "(error)"},
+		{"A=90", "A+10", OverflowError, "{A=100}"},
+	}
+
+	for _, test := range tests {
+		state := StateOf(test.start)
+		err := state.apply(StateOf(test.effect), test.overflow, StanceExpected)
+		if test.expect == "(error)" {
+			assert.ErrorIs(t, err, ErrOverflow)
+			continue
+		}
+
+		assert.NoError(t, err)
+		assert.Equal(t, test.expect, state.String())
+	}
+}
+
+func TestWithBase(t *testing.T) {
+	world := StateOf("door", "!key", "gold=50")
+	agent := StateOf("key").WithBase(world)
+
+	flat := StateOf("door", "key", "gold=50")
+	assert.Equal(t, flat.Hash(), agent.Hash())
+	assert.Equal(t, flat.String(), agent.String())
+
+	match, err := agent.Match(StateOf("door", "key", "gold>40"))
+	assert.NoError(t, err)
+	assert.True(t, match)
+	assert.Equal(t, float32(50), agent.Distance(StateOf("gold=100")))
+
+	// Writes only go to the overlay, and clones share the base
+	clone := agent.Clone()
+	assert.NoError(t, clone.Apply(StateOf("gold+20", "!door")))
+	assert.Equal(t, StateOf("!door", "key", "gold=70").Hash(), clone.Hash())
+	assert.Equal(t, StateOf("door", "!key", "gold=50").Hash(), world.Hash())
+
+	// Deleting from the overlay reveals the base again
+	assert.NoError(t, clone.Del("gold"))
+	assert.Equal(t, StateOf("!door", "key", "gold=50").Hash(), clone.Hash())
+
+	// Removing the base leaves the overlay only
+	assert.Equal(t, StateOf("key").Hash(), agent.WithBase(nil).Hash())
+
+	plan, err := Plan(StateOf("key").WithBase(world), StateOf("gold=100"), []Action{
+		actionOf("Loot", 1, StateOf("door", "key"), StateOf("gold+50")),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Loot"}, planOf(plan))
+}
+
+func TestHistory(t *testing.T) {
+	state := StateOf("hunger=50")
+	assert.NoError(t, state.Apply(StateOf("hunger+10")))
+	assert.Nil(t, state.History())
+
+	state.EnableHistory(2)
+	eat := actionOf("Eat", 1, StateOf(), StateOf("hunger-40"))
+	assert.NoError(t, state.Apply(StateOf("hunger+10")))
+	assert.NoError(t, state.ApplyAction(eat, StateOf("hunger-40")))
+	assert.NoError(t, state.ApplyAction(eat, StateOf("hunger-40")))
+
+	history := state.History()
+	assert.Len(t, history, 2)
+	assert.Equal(t, eat, history[1].Action)
+	assert.Equal(t, "hunger-40", history[1].Effects[0].String())
+	assert.Equal(t, "hunger=30", history[0].Result[0].String())
+	assert.Equal(t, "hunger=0", history[1].Result[0].String())
+
+	// Clones do not carry the history over
+	assert.Nil(t, state.Clone().History())
+}
+
+func TestTryStateOf(t *testing.T) {
+	state, err := TryStateOf("hunger=80", "!food")
+	assert.NoError(t, err)
+	assert.Equal(t, StateOf("hunger=80", "!food").Hash(), state.Hash())
+
+	_, err = TryStateOf("hunger=80", "food>=10", "tired=200")
+	assert.ErrorContains(t, err, "did you mean '>'")
+	assert.ErrorIs(t, err, ErrValueRange)
 }
@@ -0,0 +1,73 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "math"
+
+// Step represents a single step of a plan, along with the states of the world predicted by
+// the planner before and after performing its action.
+type Step struct {
+	Action Action // The action to perform
+	Before *State // The predicted state before performing the action
+	After  *State // The predicted state after performing the action
+}
+
+// Expects returns whether the actual state of the world, once the action is performed,
+// matches the predicted one. An executor can use it to detect that reality diverged from
+// the expectation and that it should replan.
+func (s Step) Expects(actual *State) (bool, error) {
+	return actual.Match(s.After)
+}
+
+// PlanSteps finds a plan to reach the goal from the start state using the provided actions
+// and returns its steps along with their predicted states.
+func PlanSteps(start, goal *State, actions []Action, opts ...Option) ([]Step, error) {
+	o := optionsOf(opts)
+	o.record = true
+	return runSteps(start, goalOf(goal), actions, o)
+}
+
+// PlanSteps finds a plan to reach the goal from the start state and returns its steps
+// along with their predicted states.
+func (p *Planner) PlanSteps(start, goal *State, opts ...Option) ([]Step, error) {
+	o := with(p.options, opts)
+	o.record = true
+	return runSteps(start, goalOf(goal), p.actions, o)
+}
+
+// runSteps runs the search and returns the steps of the plan found.
+func runSteps(start *State, goal objective, actions []Action, o options) ([]Step, error) {
+	var search Search
+	search.init(nil, start, goal, actions, o)
+	for !search.Step(math.MaxInt) {
+	}
+
+	if search.final != nil {
+		search.final.release()
+	}
+
+	if _, err := search.Result(); err != nil {
+		return nil, err
+	}
+	return search.steps, nil
+}
+
+// reconstructSteps appends the steps from the start node to the goal node, along with
+// a copy of their states.
+func reconstructSteps(steps []Step, goalNode *State) []Step {
+	offset := len(steps)
+	for n := goalNode; n != nil && n.parent != nil; n = n.parent {
+		steps = append(steps, Step{
+			Action: n.action,
+			Before: n.parent.Clone(),
+			After:  n.Clone(),
+		})
+	}
+
+	// Reverse the steps because we traversed the nodes from goal to start
+	for i, j := offset, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
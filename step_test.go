@@ -0,0 +1,60 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSteps(t *testing.T) {
+	start := StateOf("hunger=80", "!food", "!tired")
+	actions := []Action{
+		actionOf("Eat", 1.0, StateOf("food>0"), StateOf("hunger-50", "food-5")),
+		actionOf("Forage", 1.0, StateOf("tired<50"), StateOf("tired+20", "food+10", "hunger+5")),
+		actionOf("Sleep", 1.0, StateOf("tired>30"), StateOf("tired-50")),
+	}
+
+	plan, err := Plan(start, StateOf("food>80"), actions)
+	assert.NoError(t, err)
+
+	steps, err := PlanSteps(start, StateOf("food>80"), actions)
+	assert.NoError(t, err)
+	assert.Len(t, steps, len(plan))
+	assert.Equal(t, start.Hash(), steps[0].Before.Hash())
+	assert.Equal(t, "{food=10, tired=20, hunger=85}", steps[0].After.String())
+
+	// Every step starts from the state predicted by the previous one
+	actual := start.Clone()
+	for i, step := range steps {
+		assert.Equal(t, plan[i], step.Action)
+		assert.Equal(t, actual.Hash(), step.Before.Hash())
+
+		_, outcome := step.Action.Simulate(actual)
+		assert.NoError(t, actual.Apply(outcome))
+		ok, err := step.Expects(actual)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	// A divergence from the prediction is detected
+	assert.NoError(t, actual.Add("food=0"))
+	ok, err := steps[len(steps)-1].Expects(actual)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestPlannerSteps(t *testing.T) {
+	planner, err := NewPlanner([]Action{move("A->B"), move("B->C"), move("C->D")})
+	assert.NoError(t, err)
+
+	steps, err := planner.PlanSteps(StateOf("A"), StateOf("D"), WithWaypoints(StateOf("B")))
+	assert.NoError(t, err)
+	assert.Len(t, steps, 3)
+	assert.Equal(t, "{D=100, C=0, B=0, A=0}", steps[2].After.String())
+
+	_, err = planner.PlanSteps(StateOf("A"), StateOf("Z"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import "sync"
+
+// SyncState represents a state of the world which can be safely shared between
+// goroutines, for example between a sensing goroutine and the planner. It cannot
+// be passed to the planner directly, instead a consistent copy of it should be
+// taken with Snapshot() and the planner then works on that copy.
+type SyncState struct {
+	lock  sync.RWMutex
+	state *State
+}
+
+// NewSyncState creates a new thread-safe state from a list of rules.
+func NewSyncState(rules ...string) *SyncState {
+	return &SyncState{
+		state: StateOf(rules...),
+	}
+}
+
+// Add adds a rule to the state.
+func (s *SyncState) Add(rule string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state.Add(rule)
+}
+
+// AddRule adds a precompiled rule to the state.
+func (s *SyncState) AddRule(rule Rule) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state.AddRule(rule)
+}
+
+// Del removes a rule from the state.
+func (s *SyncState) Del(rule string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state.Del(rule)
+}
+
+// Apply applies the effects to the state.
+func (s *SyncState) Apply(effects *State) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state.Apply(effects)
+}
+
+// Match checks if the state satisfies all the rules of the other state.
+func (s *SyncState) Match(needs *State) (bool, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.state.Match(needs)
+}
+
+// Snapshot returns a copy of the state at this point in time. The copy is not
+// affected by any subsequent changes and can be passed to the planner.
+func (s *SyncState) Snapshot() *State {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.state.Clone()
+}
+
+// Freeze returns an immutable snapshot of the state at this point in time, which can be
+// shared by many concurrent planners without cloning the whole world for each of them.
+func (s *SyncState) Freeze() *WorldSnapshot {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return Freeze(s.state)
+}
+
+// Hash returns a hash of the state.
+func (s *SyncState) Hash() uint32 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.state.Hash()
+}
+
+// String returns a string representation of the state.
+func (s *SyncState) String() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.state.String()
+}
+
+// ------------------------------------ Snapshot ------------------------------------
+
+// WorldSnapshot represents a frozen state of the world. Since it can never be modified,
+// any number of goroutines can safely match against it, and each search only keeps its
+// private deltas in an overlay state built with Overlay().
+type WorldSnapshot struct {
+	state *State
+}
+
+// Freeze creates an immutable snapshot of the state, which is not affected by any of the
+// subsequent changes of the state.
+func Freeze(state *State) *WorldSnapshot {
+	return &WorldSnapshot{
+		state: state.Flatten(),
+	}
+}
+
+// Overlay creates a new private state on top of the snapshot, with the rules overriding
+// the ones of the snapshot. The overlay can be modified and passed to the planner, while
+// the snapshot remains shared.
+func (w *WorldSnapshot) Overlay(rules ...string) (*State, error) {
+	state, err := TryStateOf(rules...)
+	if err != nil {
+		return nil, err
+	}
+
+	return state.WithBase(w.state), nil
+}
+
+// Match checks if the snapshot satisfies all the rules of the other state.
+func (w *WorldSnapshot) Match(needs *State) (bool, error) {
+	return w.state.Match(needs)
+}
+
+// Hash returns a hash of the snapshot.
+func (w *WorldSnapshot) Hash() uint32 {
+	return w.state.Hash()
+}
+
+// String returns a string representation of the snapshot.
+func (w *WorldSnapshot) String() string {
+	return w.state.String()
+}
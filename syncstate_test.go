@@ -0,0 +1,87 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncState(t *testing.T) {
+	state := NewSyncState("hunger=80", "!food", "!tired")
+	goal := StateOf("food>80")
+	actions := []Action{
+		actionOf("Eat", 1.0, StateOf("food>0"), StateOf("hunger-50", "food-5")),
+		actionOf("Forage", 1.0, StateOf("tired<50"), StateOf("tired+20", "food+10", "hunger+5")),
+		actionOf("Sleep", 1.0, StateOf("tired>30"), StateOf("tired-50")),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	// Sensing goroutine, continuously updating the state
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			assert.NoError(t, state.Add("hunger=80"))
+			assert.NoError(t, state.Apply(StateOf("noise+1")))
+			assert.NoError(t, state.Del("noise"))
+		}
+	}()
+
+	// Planning goroutine, working on snapshots
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			plan, err := Plan(state.Snapshot(), goal, actions)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, plan)
+		}
+	}()
+
+	wg.Wait()
+	ok, err := state.Match(StateOf("hunger=80"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, StateOf("hunger=80", "!food", "!tired").Hash(), state.Hash())
+	assert.Equal(t, StateOf("hunger=80", "!food", "!tired").String(), state.String())
+}
+
+func TestWorldSnapshot(t *testing.T) {
+	world := NewSyncState("door", "gold=50")
+	snapshot := world.Freeze()
+	assert.NoError(t, world.Add("!door"))
+
+	match, err := snapshot.Match(StateOf("door"))
+	assert.NoError(t, err)
+	assert.True(t, match)
+	assert.Equal(t, StateOf("door", "gold=50").Hash(), snapshot.Hash())
+
+	// Many agents plan concurrently over the same snapshot with their own deltas
+	actions := []Action{
+		actionOf("Loot", 1, StateOf("door", "key"), StateOf("gold+50")),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start, err := snapshot.Overlay("key")
+			assert.NoError(t, err)
+
+			plan, err := Plan(start, StateOf("gold=100"), actions)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"Loot"}, planOf(plan))
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, StateOf("door", "gold=50").String(), snapshot.String())
+
+	_, err = snapshot.Overlay("key>=1")
+	assert.Error(t, err)
+}
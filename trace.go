@@ -0,0 +1,136 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// traceEvent represents a single event of the Chrome trace event format.
+type traceEvent struct {
+	Name  string     `json:"name"`
+	Cat   string     `json:"cat"`
+	Phase string     `json:"ph"`
+	Time  float64    `json:"ts"`
+	Dur   float64    `json:"dur,omitempty"`
+	Pid   int        `json:"pid"`
+	Tid   int        `json:"tid"`
+	Scope string     `json:"s,omitempty"`
+	Args  *traceArgs `json:"args,omitempty"`
+}
+
+// traceArgs represents the arguments of a trace event.
+type traceArgs struct {
+	State string  `json:"state,omitempty"`
+	Depth int     `json:"depth"`
+	Cost  float32 `json:"cost"`
+}
+
+// tracer records the timeline of a search. All of its methods are no-ops on a nil tracer,
+// so the search only pays for the tracing when it is enabled.
+type tracer struct {
+	dst    io.Writer    // The destination of the trace
+	epoch  time.Time    // The start of the search
+	events []traceEvent // The events recorded so far
+}
+
+// newTracer creates a new tracer writing into the destination, if any.
+func newTracer(dst io.Writer) *tracer {
+	if dst == nil {
+		return nil
+	}
+
+	return &tracer{
+		dst:    dst,
+		epoch:  time.Now(),
+		events: make([]traceEvent, 0, 256),
+	}
+}
+
+// now returns the current time, if tracing.
+func (t *tracer) now() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// expand records the expansion of the node, which started at the specified time.
+func (t *tracer) expand(start time.Time, node *State) {
+	if t != nil {
+		t.span("search", "expand", start, node)
+	}
+}
+
+// simulate records the simulation of the action, which started at the specified time.
+func (t *tracer) simulate(start time.Time, action Action) {
+	if t != nil {
+		t.span("simulate", nameOf(action), start, nil)
+	}
+}
+
+// heap records an operation on the open set of the search, such as a push.
+func (t *tracer) heap(op string, node *State) {
+	if t == nil {
+		return
+	}
+
+	t.events = append(t.events, traceEvent{
+		Name:  op,
+		Cat:   "heap",
+		Phase: "i",
+		Time:  t.since(time.Now()),
+		Pid:   1,
+		Tid:   1,
+		Scope: "t",
+		Args:  argsOf(node),
+	})
+}
+
+// span records a complete event which started at the specified time.
+func (t *tracer) span(cat, name string, start time.Time, node *State) {
+	t.events = append(t.events, traceEvent{
+		Name:  name,
+		Cat:   cat,
+		Phase: "X",
+		Time:  t.since(start),
+		Dur:   float64(time.Since(start).Nanoseconds()) / 1e3,
+		Pid:   1,
+		Tid:   1,
+		Args:  argsOf(node),
+	})
+}
+
+// since returns the time elapsed between the start of the search and the specified time,
+// in microseconds.
+func (t *tracer) since(at time.Time) float64 {
+	return float64(at.Sub(t.epoch).Nanoseconds()) / 1e3
+}
+
+// flush records the whole search as a single event and writes the trace.
+func (t *tracer) flush() error {
+	if t == nil {
+		return nil
+	}
+
+	t.span("search", "plan", t.epoch, nil)
+	return json.NewEncoder(t.dst).Encode(struct {
+		Events []traceEvent `json:"traceEvents"`
+	}{t.events})
+}
+
+// argsOf returns the trace arguments describing the node, if any.
+func argsOf(node *State) *traceArgs {
+	if node == nil {
+		return nil
+	}
+
+	return &traceArgs{
+		State: node.String(),
+		Depth: node.depth,
+		Cost:  node.stateCost,
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Unit represents the unit of the values of a fact.
+type Unit uint8
+
+const (
+	UnitPercent Unit = iota // Percentage, the default unit of the facts
+	UnitCount               // Count of items, such as ammo
+	UnitMeters              // Distance in meters
+	UnitSeconds             // Duration in seconds
+)
+
+// String returns the suffix of the unit, used when parsing and printing the values.
+func (u Unit) String() string {
+	switch u {
+	case UnitPercent:
+		return "%"
+	case UnitMeters:
+		return "m"
+	case UnitSeconds:
+		return "s"
+	default:
+		return ""
+	}
+}
+
+// domain represents the unit and the range of the values of a declared fact, which are
+// mapped onto the internal range of the values so they can be planned as usual.
+type domain struct {
+	unit Unit    // The unit of the values
+	min  float32 // The minimum value
+	max  float32 // The maximum value
+}
+
+// domains maps the declared facts to their units and ranges.
+var domains struct {
+	sync.RWMutex
+	facts map[fact]domain
+}
+
+// DeclareFact declares the unit and the range of the values of a fact, such as a distance
+// between 0 and 1000 meters, instead of an implicit percentage. The values of the fact are
+// then parsed, validated and printed in that unit, and normalized onto the internal range
+// so that the distances of the different facts remain comparable. Facts must be declared
+// before parsing any rule using them.
+func DeclareFact(name string, unit Unit, min, max float32) error {
+	if !(min < max) || math.IsInf(float64(max-min), 0) {
+		return &FactError{Fact: name, Err: fmt.Errorf("plan: invalid range [%v, %v] of fact '%s'", min, max, name)}
+	}
+
+	domains.Lock()
+	defer domains.Unlock()
+	if domains.facts == nil {
+		domains.facts = make(map[fact]domain, 8)
+	}
+
+	domains.facts[factOf(name)] = domain{unit: unit, min: min, max: max}
+	ruleCache.Reset()
+	return nil
+}
+
+// domainOf returns the unit and the range of the fact, if declared.
+func domainOf(f fact) (domain, bool) {
+	domains.RLock()
+	defer domains.RUnlock()
+	d, ok := domains.facts[f]
+	return d, ok
+}
+
+// trim trims the unit suffix from the value, if present.
+func (d domain) trim(value string) string {
+	return strings.TrimSuffix(value, d.unit.String())
+}
+
+// bounds returns the range of the values for the operator, deltas being relative.
+func (d domain) bounds(op operator) (float32, float32) {
+	switch op {
+	case opIncrement, opDecrement:
+		return 0, d.max - d.min
+	default:
+		return d.min, d.max
+	}
+}
+
+// normalize maps the value expressed in the unit of the fact onto the internal range.
+func (d domain) normalize(op operator, value float32) float32 {
+	lo, hi := d.bounds(op)
+	return (value - lo) / (hi - lo) * (MaxValue - MinValue)
+}
+
+// denormalize maps the internal value back onto the range of the fact.
+func (d domain) denormalize(op operator, value float32) float32 {
+	lo, hi := d.bounds(op)
+	return lo + value/(MaxValue-MinValue)*(hi-lo)
+}
+
+// format returns the string representation of the expression in the unit of the fact,
+// rounded to the resolution of its range.
+func (d domain) format(e expr) string {
+	switch lo, hi, ok := e.Band(); {
+	case ok && e.Operator() == opBetween:
+		return ">" + d.value(opGreater, lo) + "<" + d.value(opLess, hi)
+	case ok:
+		return e.Operator().String() + d.value(opIncrement, lo) + ".." + d.value(opIncrement, hi)
+	default:
+		return e.Operator().String() + d.value(e.Operator(), e.Fixed())
+	}
+}
+
+// value returns the string representation of the fixed-point value in the unit of the
+// fact, rounded to the resolution of its range.
+func (d domain) value(op operator, fixed int32) string {
+	lo, hi := d.bounds(op)
+	step := float64(hi-lo) / ((MaxValue - MinValue) * float64(scale))
+	decimals := int(max(0, math.Ceil(-math.Log10(step))))
+
+	v := float32(float64(fixed) / float64(scale))
+	value := strconv.FormatFloat(float64(d.denormalize(op, v)), 'f', decimals, 64)
+	if strings.Contains(value, ".") {
+		value = strings.TrimRight(strings.TrimRight(value, "0"), ".")
+	}
+	return value + d.unit.String()
+}
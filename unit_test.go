@@ -0,0 +1,41 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeclareFact(t *testing.T) {
+	// The declarations are never removed, so the names are unique to this test
+	assert.NoError(t, DeclareFact("unit_range", UnitMeters, 0, 1000))
+	assert.NoError(t, DeclareFact("unit_ammo", UnitCount, 0, 200))
+	assert.Error(t, DeclareFact("unit_broken", UnitCount, 10, 10))
+
+	state := StateOf("unit_range=250m", "unit_ammo=150")
+	assert.Contains(t, state.String(), "unit_range=250m")
+	assert.Contains(t, state.String(), "unit_ammo=150")
+
+	// Effects are expressed in the unit of the fact as well
+	assert.NoError(t, state.Apply(StateOf("unit_range-50", "unit_ammo+40")))
+	match, err := state.Match(StateOf("unit_range<201", "unit_ammo>189"))
+	assert.NoError(t, err)
+	assert.True(t, match)
+
+	// Values are validated against the declared range
+	_, err = CompileRule("unit_range=1200")
+	assert.ErrorIs(t, err, ErrValueRange)
+	assert.ErrorContains(t, err, "expected a number between 0 and 1000")
+
+	rule, err := CompileRule("unit_ammo>120")
+	assert.NoError(t, err)
+	assert.Equal(t, "unit_ammo>120", rule.String())
+
+	// Both bounds of a range are expressed in the unit of the fact
+	rule, err = CompileRule("unit_range>100m<300m")
+	assert.NoError(t, err)
+	assert.Equal(t, "unit_range>100m<300m", rule.String())
+}
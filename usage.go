@@ -0,0 +1,142 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"sort"
+	"sync"
+)
+
+// ActionUsage represents how a single action was used by the plans recorded in analytics.
+type ActionUsage struct {
+	Action   string  // The name of the action
+	Plans    uint64  // Number of plans containing the action
+	Uses     uint64  // Number of times the action appears, across all the plans
+	Position float32 // Average index of the action in the plans, starting at 0
+	Cost     float32 // Total cost of the action, across all the plans
+	Share    float32 // Fraction of the total cost of all the plans spent on the action
+}
+
+// Analytics represents how often every action appears in the plans found, at which
+// position and how much of their cost it accounts for, across many searches. Designers use
+// it to find the actions which are never used, or the overpowered ones used in every plan.
+// Analytics accumulate the plans of every search they are passed to with WithAnalytics and
+// are safe for concurrent use.
+type Analytics struct {
+	lock  sync.Mutex
+	plans uint64            // Number of plans recorded
+	cost  float32           // Total cost of the plans recorded
+	usage map[string]*tally // The tallies of the actions, by name
+}
+
+// tally represents the tallies of a single action.
+type tally struct {
+	plans    uint64  // Number of plans containing the action
+	uses     uint64  // Number of times the action appears
+	position uint64  // Sum of the indices of the action in the plans
+	cost     float32 // Total cost of the action
+	last     uint64  // The last plan containing the action
+}
+
+// WithAnalytics records the plan returned, if any, into the analytics. Every call records
+// a single plan, even if it runs several searches to find it.
+func WithAnalytics(dst *Analytics) Option {
+	return func(o *options) {
+		o.analytics = dst
+	}
+}
+
+// Plans returns the number of plans recorded.
+func (a *Analytics) Plans() uint64 {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.plans
+}
+
+// Report returns the usage of every action which appeared in any plan, from the most to
+// the least used one.
+func (a *Analytics) Report() []ActionUsage {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	out := make([]ActionUsage, 0, len(a.usage))
+	for name, u := range a.usage {
+		v := ActionUsage{
+			Action:   name,
+			Plans:    u.plans,
+			Uses:     u.uses,
+			Position: float32(u.position) / float32(u.uses),
+			Cost:     u.cost,
+		}
+		if a.cost > 0 {
+			v.Share = u.cost / a.cost
+		}
+		out = append(out, v)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Uses != out[j].Uses {
+			return out[i].Uses > out[j].Uses
+		}
+		return out[i].Action < out[j].Action
+	})
+	return out
+}
+
+// Unused returns the names of the actions which never appeared in any plan.
+func (a *Analytics) Unused(actions []Action) []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var out []string
+	for _, action := range actions {
+		if name := nameOf(action); a.usage[name] == nil {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// Reset clears all of the tallies.
+func (a *Analytics) Reset() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.plans = 0
+	a.cost = 0
+	clear(a.usage)
+}
+
+// record tallies the actions of the plan.
+func (a *Analytics) record(plan []Action) {
+	if a == nil {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.usage == nil {
+		a.usage = make(map[string]*tally, 16)
+	}
+
+	a.plans++
+	for i, action := range plan {
+		name := nameOf(action)
+		u, ok := a.usage[name]
+		if !ok {
+			u = new(tally)
+			a.usage[name] = u
+		}
+
+		if u.last != a.plans {
+			u.last = a.plans
+			u.plans++
+		}
+
+		cost := action.Cost()
+		u.uses++
+		u.position += uint64(i)
+		u.cost += cost
+		a.cost += cost
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalytics(t *testing.T) {
+	actions := []Action{
+		move("A->B"), move("B->C", 2), move("C->D"), move("D->A"),
+	}
+
+	var analytics Analytics
+	for _, start := range []string{"A", "B", "A"} {
+		_, err := Plan(StateOf(start), StateOf("C"), actions, WithAnalytics(&analytics))
+		assert.NoError(t, err)
+	}
+
+	// Failed searches are not recorded
+	_, err := Plan(StateOf("E"), StateOf("C"), actions, WithAnalytics(&analytics))
+	assert.Error(t, err)
+	assert.Equal(t, uint64(3), analytics.Plans())
+
+	assert.Equal(t, []ActionUsage{
+		{Action: "B->C", Plans: 3, Uses: 3, Position: 2.0 / 3, Cost: 6, Share: 0.75},
+		{Action: "A->B", Plans: 2, Uses: 2, Position: 0, Cost: 2, Share: 0.25},
+	}, analytics.Report())
+	assert.Equal(t, []string{"C->D", "D->A"}, analytics.Unused(actions))
+
+	analytics.Reset()
+	assert.Zero(t, analytics.Plans())
+	assert.Empty(t, analytics.Report())
+}
+
+func TestAnalyticsOncePerPlan(t *testing.T) {
+	actions := []Action{
+		actionOf("set a", 1, StateOf("!usage_a"), StateOf("usage_a")),
+		actionOf("set b", 1, StateOf("!usage_b"), StateOf("usage_b")),
+		actionOf("set c", 1, StateOf("!usage_c"), StateOf("usage_c")),
+	}
+
+	// A decomposed plan is recorded once, rather than once per subgoal
+	var analytics Analytics
+	plan, err := PlanDecomposed(StateOf("!usage_a", "!usage_b", "!usage_c"), StateOf("usage_a", "usage_b", "usage_c"), actions, WithAnalytics(&analytics))
+	assert.NoError(t, err)
+	assert.Len(t, plan, 3)
+	assert.Equal(t, uint64(1), analytics.Plans())
+	for _, usage := range analytics.Report() {
+		assert.Equal(t, uint64(1), usage.Plans)
+		assert.Equal(t, uint64(1), usage.Uses)
+	}
+
+	// Only the best plan of the anytime search is recorded
+	analytics.Reset()
+	_, err = PlanAnytime(StateOf("!usage_a", "!usage_b", "!usage_c"), StateOf("usage_a", "usage_b", "usage_c"), actions, time.Now().Add(time.Second), nil, WithAnalytics(&analytics))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), analytics.Plans())
+
+	// The best plan within the budget is recorded, even if it doesn't reach every goal
+	analytics.Reset()
+	result, err := PlanWeighted(StateOf("!usage_a", "!usage_b", "!usage_c"), []WeightedGoal{
+		{Goal: StateOf("usage_a"), Weight: 1},
+		{Goal: StateOf("usage_b"), Weight: 2},
+		{Goal: StateOf("usage_c"), Weight: 3},
+	}, 2, actions, WithAnalytics(&analytics))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"set b", "set c"}, planOf(result.Plan))
+	assert.Equal(t, uint64(1), analytics.Plans())
+	assert.Equal(t, []string{"set a"}, analytics.Unused(actions))
+}
@@ -0,0 +1,332 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"errors"
+	"fmt"
+)
+
+// IssueKind represents a kind of issue found in a domain.
+type IssueKind uint8
+
+const (
+	IssueContradictory IssueKind = iota // Effects that can never be applied or never change anything
+	IssueUnreachable                    // Preconditions that can never be satisfied
+	IssueUnused                         // Effects no other action or goal ever reads
+	IssueDuplicate                      // Actions with the same requirements, outcomes and cost
+	IssueUncovered                      // Goal facts no action outcome can influence
+)
+
+// String returns the string representation of the issue kind.
+func (k IssueKind) String() string {
+	switch k {
+	case IssueContradictory:
+		return "contradictory"
+	case IssueUnreachable:
+		return "unreachable"
+	case IssueUnused:
+		return "unused"
+	case IssueDuplicate:
+		return "duplicate"
+	case IssueUncovered:
+		return "uncovered"
+	default:
+		return "unknown"
+	}
+}
+
+// Issue represents a single issue found in a domain.
+type Issue struct {
+	Kind    IssueKind // The kind of the issue
+	Action  Action    // The action with the issue, nil for goal issues
+	Fact    string    // The fact concerned, if any
+	Message string    // The human-readable description of the issue
+}
+
+// Error returns the error message of the issue.
+func (i Issue) Error() string {
+	if i.Action == nil {
+		return fmt.Sprintf("plan: %s goal, %s", i.Kind, i.Message)
+	}
+
+	return fmt.Sprintf("plan: %s action '%s', %s", i.Kind, nameOf(i.Action), i.Message)
+}
+
+// Report represents the result of a domain validation.
+type Report []Issue
+
+// Err returns all of the issues joined into a single error, or nil if the domain is valid.
+func (r Report) Err() error {
+	errs := make([]error, 0, len(r))
+	for _, issue := range r {
+		errs = append(errs, issue)
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateDomain validates the set of actions along with the goals and reports the
+// issues found. Actions are simulated against an empty state, hence for actions that
+// depend on the current state the report is only indicative.
+func ValidateDomain(actions []Action, goals ...*State) Report {
+	type spec struct {
+		require, outcome *State
+	}
+
+	specs := make([]spec, 0, len(actions))
+	defer func() {
+		for _, s := range specs {
+			s.require.release()
+			s.outcome.release()
+		}
+	}()
+
+	reads := make(map[fact]bool, 16)
+	writes := make(map[fact]bool, 16)
+	for _, action := range actions {
+		require, outcome := describe(action, nil)
+		specs = append(specs, spec{require, outcome})
+		for _, r := range require.vx {
+			reads[r.Fact()] = true
+		}
+		for _, r := range outcome.vx {
+			writes[r.Fact()] = true
+		}
+	}
+
+	for _, goal := range goals {
+		for _, r := range goal.vx {
+			reads[r.Fact()] = true
+		}
+	}
+
+	var report Report
+	issue := func(kind IssueKind, action Action, f fact, format string, args ...any) {
+		var name string
+		if f != 0 {
+			name = f.String()
+		}
+
+		report = append(report, Issue{
+			Kind:    kind,
+			Action:  action,
+			Fact:    name,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for i, action := range actions {
+		require, outcome := specs[i].require, specs[i].outcome
+
+		// Requirements must be comparisons and must be satisfiable
+		for _, r := range require.vx {
+			f, e := r.Fact(), r.Expr()
+			switch {
+			case e.Operator() == opIncrement || e.Operator() == opDecrement,
+				e.Operator() == opIncrementRef || e.Operator() == opDecrementRef,
+				e.Operator() == opIncrementAny || e.Operator() == opDecrementAny:
+				issue(IssueUnreachable, action, f, "precondition '%s' is not a comparison", r)
+			case e.Operator() == opLess && e.Value() <= MinValue:
+				issue(IssueUnreachable, action, f, "precondition '%s' can never be satisfied", r)
+			case e.Operator() == opGreater && e.Value() >= MaxValue:
+				issue(IssueUnreachable, action, f, "precondition '%s' can never be satisfied", r)
+			case !writes[f] && e != exprOf(opEqual, 0):
+				issue(IssueUnreachable, action, f, "precondition '%s' is never produced by any action and must hold initially", r)
+			}
+		}
+
+		// Effects must be applicable and must change the state
+		changes := false
+		for _, r := range outcome.vx {
+			f, e := r.Fact(), r.Expr()
+			switch e.Operator() {
+			case opLess, opGreater, opBetween:
+				issue(IssueContradictory, action, f, "effect '%s' is a comparison and cannot be applied", r)
+				continue
+			case opIncrement, opDecrement:
+				changes = changes || e.Value() != 0
+			case opIncrementRef, opDecrementRef, opIncrementAny, opDecrementAny:
+				changes = true
+			case opEqual:
+				if x, ok := require.find(f); !ok || require.vx[x].Expr() != e {
+					changes = true
+				}
+			}
+
+			if !reads[f] {
+				issue(IssueUnused, action, f, "effect '%s' is never read by any action or goal", r)
+			}
+		}
+
+		if !changes {
+			issue(IssueContradictory, action, 0, "effects never change the state")
+		}
+
+		// Check for duplicates of the previous actions
+		for j := 0; j < i; j++ {
+			if action.Cost() == actions[j].Cost() &&
+				require.Equals(specs[j].require) &&
+				outcome.Equals(specs[j].outcome) {
+				issue(IssueDuplicate, action, 0, "duplicate of action '%s'", nameOf(actions[j]))
+				break
+			}
+		}
+	}
+
+	// Goal facts must be influenced by at least one action
+	for _, goal := range goals {
+		for _, r := range goal.vx {
+			if !writes[r.Fact()] {
+				issue(IssueUncovered, nil, r.Fact(), "fact '%s' cannot be influenced by any action", r)
+			}
+		}
+	}
+
+	return report
+}
+
+// Uncovered returns the facts of the goal which cannot be influenced by the outcome
+// of any of the actions. Unless these facts already hold in the initial state, the
+// goal can never be reached.
+func Uncovered(goal *State, actions []Action) (out []string) {
+	writes := make(map[fact]bool, 16)
+	for _, action := range actions {
+		require, outcome := describe(action, goal)
+		for _, r := range outcome.vx {
+			writes[r.Fact()] = true
+		}
+
+		require.release()
+		outcome.release()
+	}
+
+	for _, r := range goal.vx {
+		if !writes[r.Fact()] {
+			out = append(out, r.Fact().String())
+		}
+	}
+	return
+}
+
+// StepError represents the failure of a step of a plan when simulating it, either because
+// the requirements of its action are not met or because its outcomes cannot be applied.
+type StepError struct {
+	Step   int    // The index of the failing step in the plan, starting at 0
+	Action Action // The action of the failing step
+	Err    error  // The underlying error, ErrPrecondition if the requirements are not met
+	detail string // The details of the unmet requirements, if any
+}
+
+// Error returns the error message.
+func (e *StepError) Error() string {
+	if e.detail != "" {
+		return fmt.Sprintf("plan: step %d, action '%s' %s", e.Step+1, nameOf(e.Action), e.detail)
+	}
+	return fmt.Sprintf("plan: step %d, action '%s': %v", e.Step+1, nameOf(e.Action), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// SimulatePlan simulates the plan by applying the outcomes of every action in order from
+// the start state, checking the requirements of each action along the way, and returns
+// the resulting state. If any step fails, it returns a *StepError with the index of the
+// failing step. The start state is not modified. The overflow and stance options are
+// applied the same way as during the search which found the plan.
+func SimulatePlan(start *State, plan []Action, opts ...Option) (*State, error) {
+	return simulatePlan(start, nil, plan, optionsOf(opts))
+}
+
+// simulatePlan simulates the plan from the start state toward the goal, which may be nil.
+func simulatePlan(start, goal *State, plan []Action, o options) (*State, error) {
+	current := start.Clone()
+	require, outcome := StateOf(), StateOf()
+	defer require.release()
+	defer outcome.release()
+
+	for i, action := range plan {
+		if err := simulateStep(current, goal, i, action, require, outcome, o.overflow, o.stance); err != nil {
+			current.release()
+			return nil, err
+		}
+	}
+
+	return current, nil
+}
+
+// simulateStep simulates the i-th action of a plan toward the goal, which may be nil, and
+// applies its outcomes to the current state with the overflow and stance.
+func simulateStep(current, goal *State, i int, action Action, require, outcome *State, overflow Overflow, stance Stance) error {
+	r, o := simulate(action, current, goal, require, outcome)
+	match, err := current.Match(r)
+	switch {
+	case err != nil:
+		return &StepError{Step: i, Action: action, Err: err}
+	case !match:
+		return &StepError{Step: i, Action: action, Err: ErrPrecondition,
+			detail: fmt.Sprintf("requires %s but state is %s", r, current)}
+	}
+
+	if ok, err := available(current, action); err != nil || !ok {
+		return &StepError{Step: i, Action: action, Err: ErrPrecondition,
+			detail: fmt.Sprintf("is not available in state %s", current)}
+	}
+
+	if err := current.apply(o, overflow, stance); err != nil {
+		return &StepError{Step: i, Action: action, Err: err}
+	}
+
+	if err := advance(current, action, overflow); err != nil {
+		return &StepError{Step: i, Action: action, Err: err}
+	}
+	return nil
+}
+
+// VerifyPlan verifies that the plan is valid by re-applying the outcomes of every action
+// starting from the start state, checking the requirements of each action along the way
+// and finally checking that the goal is reached. The overflow and stance options are
+// applied the same way as during the search which found the plan.
+func VerifyPlan(start, goal *State, plan []Action, opts ...Option) error {
+	current, err := simulatePlan(start, goal, plan, optionsOf(opts))
+	if err != nil {
+		return err
+	}
+
+	defer current.release()
+	match, err := current.Match(goal)
+	switch {
+	case err != nil:
+		return err
+	case !match:
+		return fmt.Errorf("plan: goal %s is not reached, final state is %s", goal, current)
+	default:
+		return nil
+	}
+}
+
+// describe simulates the action against an empty state toward the goal, which may be nil,
+// and returns copies of its requirements and outcomes, to be released by the caller.
+func describe(action Action, goal *State) (*State, *State) {
+	current, require, outcome := StateOf(), StateOf(), StateOf()
+	defer current.release()
+	defer require.release()
+	defer outcome.release()
+
+	r, o := simulate(action, current, goal, require, outcome)
+	return r.Clone(), o.Clone()
+}
+
+// nameOf returns the name of the action.
+func nameOf(action Action) string {
+	switch v := action.(type) {
+	case nil:
+		return "<nil>"
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%T", action)
+	}
+}
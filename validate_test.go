@@ -0,0 +1,150 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateDomain(t *testing.T) {
+	report := ValidateDomain([]Action{
+		actionOf("Eat", 1.0, StateOf("food>0"), StateOf("hunger-50", "food-5")),
+		actionOf("Forage", 1.0, StateOf("tired<50"), StateOf("tired+20", "food+10", "hunger+5")),
+		actionOf("Sleep", 1.0, StateOf("tired>30"), StateOf("tired-50")),
+	}, StateOf("food>80", "hunger<50"))
+	assert.Empty(t, report)
+	assert.NoError(t, report.Err())
+}
+
+func TestValidateDomainIssues(t *testing.T) {
+	report := ValidateDomain([]Action{
+		actionOf("Compare", 1.0, StateOf("A"), StateOf("B>10")),
+		actionOf("Noop", 1.0, StateOf("A"), StateOf("A")),
+		actionOf("Never", 1.0, StateOf("B>100"), StateOf("C")),
+		actionOf("Orphan", 1.0, StateOf("A"), StateOf("D")),
+		actionOf("Twin", 1.0, StateOf("A"), StateOf("C")),
+		actionOf("Twin2", 1.0, StateOf("A"), StateOf("C")),
+		actionOf("Locked", 1.0, StateOf("key"), StateOf("C")),
+	}, StateOf("C", "E"))
+	assert.Error(t, report.Err())
+
+	issues := make(map[string]IssueKind)
+	for _, issue := range report {
+		issues[nameOf(issue.Action)+":"+issue.Fact] = issue.Kind
+	}
+
+	assert.Equal(t, map[string]IssueKind{
+		"Compare:B":  IssueContradictory,
+		"Compare:":   IssueContradictory,
+		"Noop:":      IssueContradictory,
+		"Never:B":    IssueUnreachable,
+		"Orphan:D":   IssueUnused,
+		"Twin2:":     IssueDuplicate,
+		"Locked:key": IssueUnreachable,
+		"<nil>:E":    IssueUncovered,
+	}, issues)
+}
+
+func TestUncovered(t *testing.T) {
+	actions := []Action{move("A->C"), move("B->C")}
+	assert.Equal(t, []string{"D"}, Uncovered(StateOf("C", "D"), actions))
+	assert.Empty(t, Uncovered(StateOf("C"), actions))
+
+	_, err := Plan(StateOf("A", "B"), StateOf("C", "D"), actions)
+	assert.ErrorContains(t, err, "facts D cannot be influenced")
+
+	_, err = Plan(StateOf("A", "B", "D=50"), StateOf("C", "D>80"), actions)
+	assert.ErrorContains(t, err, "facts D cannot be influenced")
+
+	// The outcomes simulated by the search explain the failure, rather than simulating
+	// the actions against an empty state
+	_, err = Plan(StateOf("A", "B"), StateOf("C", "D"), []Action{move("A->C"), &stateful{}})
+	assert.ErrorContains(t, err, "facts D cannot be influenced")
+}
+
+// stateful represents an action which can only be simulated from a non-empty state.
+type stateful struct{}
+
+func (a *stateful) Simulate(current *State) (*State, *State) {
+	if current.Len() == 0 {
+		panic("empty state")
+	}
+	return StateOf("B"), StateOf("!B", "E")
+}
+
+func (a *stateful) Cost() float32 {
+	return 1
+}
+
+func TestVerifyPlan(t *testing.T) {
+	start, goal := StateOf("A", "B"), StateOf("C", "D")
+	assert.NoError(t, VerifyPlan(start, goal, []Action{move("A->C"), move("B->D")}))
+	assert.ErrorContains(t, VerifyPlan(start, goal, []Action{move("A->C")}), "goal")
+	assert.ErrorContains(t, VerifyPlan(start, goal, []Action{move("C->D")}), "step 1, action 'C->D' requires")
+	assert.NoError(t, VerifyPlan(StateOf("!count"), StateOf("count>50"), []Action{
+		&scratchAction{name: "Count", step: 20},
+		&scratchAction{name: "Count", step: 20},
+		&scratchAction{name: "Count", step: 20},
+	}))
+}
+
+func TestSimulatePlan(t *testing.T) {
+	start := StateOf("A", "B")
+	final, err := SimulatePlan(start, []Action{move("A->C"), move("B->D")})
+	assert.NoError(t, err)
+	assert.Equal(t, StateOf("!A", "!B", "C", "D").Hash(), final.Hash())
+	assert.Equal(t, StateOf("A", "B").Hash(), start.Hash())
+
+	// The index of the first failing step is reported
+	_, err = SimulatePlan(start, []Action{move("A->C"), move("C->E"), move("A->D")})
+	var stepErr *StepError
+	assert.ErrorAs(t, err, &stepErr)
+	assert.ErrorIs(t, err, ErrPrecondition)
+	assert.Equal(t, 2, stepErr.Step)
+	assert.Equal(t, "A->D", nameOf(stepErr.Action))
+	assert.ErrorContains(t, err, "step 3, action 'A->D' requires")
+}
+
+func TestVerifyPlanOptions(t *testing.T) {
+	inc := actionOf("inc", 1, StateOf(), StateOf("x+60"))
+	plan, err := Plan(StateOf("x=50"), StateOf("x<20"), []Action{inc}, WithOverflow(OverflowWrap))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"inc"}, planOf(plan))
+
+	// The plan is verified with the same overflow as the search
+	assert.NoError(t, VerifyPlan(StateOf("x=50"), StateOf("x<20"), plan, WithOverflow(OverflowWrap)))
+	assert.ErrorContains(t, VerifyPlan(StateOf("x=50"), StateOf("x<20"), plan), "final state is {x=100}")
+
+	// The plan is simulated with the same stance as the search
+	grow := []Action{actionOf("grow", 1, StateOf(), StateOf("x+10..30"))}
+	final, err := SimulatePlan(StateOf("x=0"), grow, WithStance(StancePessimistic))
+	assert.NoError(t, err)
+	assert.Equal(t, "{x=10}", final.String())
+
+	final, err = SimulatePlan(StateOf("x=0"), grow)
+	assert.NoError(t, err)
+	assert.Equal(t, "{x=20}", final.String())
+}
+
+func TestDescribeCopies(t *testing.T) {
+	action := &scratchAction{name: "Count", step: 20}
+	require, outcome := describe(action, nil)
+	assert.Equal(t, "{count+20}", outcome.String())
+
+	// The scratch states are released, hence describing again doesn't change the copies
+	other, _ := describe(&scratchAction{name: "Count", step: 30}, nil)
+	assert.Equal(t, "{count+20}", outcome.String())
+	assert.NotSame(t, require, other)
+	require.release()
+	outcome.release()
+	other.release()
+
+	// The states owned by the action are never released
+	defined := actionOf("Move", 1, StateOf("describe_a"), StateOf("!describe_a", "describe_b"))
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "{describe_a=0, describe_b=100}", StateOfRules(DescribeAction(defined).Outcome...).String())
+	}
+}
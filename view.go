@@ -0,0 +1,77 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+// ViewSimulator can be optionally implemented by actions which only need to read the
+// current state. Instead of the mutable node of the search, the planner passes a read-only
+// view of it, so the action can't accidentally modify a node and corrupt the search.
+type ViewSimulator interface {
+
+	// SimulateView returns requirements and outcomes given a read-only view of the current
+	// state (model) of the world.
+	SimulateView(current View) (require, outcome *State)
+}
+
+// View represents a read-only view of a state, which does not copy the state. The view is
+// only valid for the duration of the call it is passed to, use Clone() to retain the state.
+type View struct {
+	state *State
+}
+
+// ViewOf returns a read-only view of the state, or of an empty state if nil.
+func ViewOf(state *State) View {
+	if state == nil {
+		state = nothing
+	}
+	return View{state: state}
+}
+
+// nothing is the empty state viewed in place of a nil state, which is never modified.
+var nothing = new(State)
+
+// Match checks whether the state satisfies the requirements.
+func (v View) Match(needs *State) (bool, error) {
+	return v.state.Match(needs)
+}
+
+// Distance estimates the distance from the state to the goal.
+func (v View) Distance(goal *State) float32 {
+	return v.state.Distance(goal)
+}
+
+// Equals returns whether the state is equal to the other state.
+func (v View) Equals(other *State) bool {
+	return v.state.Equals(other)
+}
+
+// Hash returns the hash of the state.
+func (v View) Hash() uint32 {
+	return v.state.Hash()
+}
+
+// Len returns the number of elements in the state.
+func (v View) Len() int {
+	return v.state.Len()
+}
+
+// Rules returns a copy of the rules of the state, including the ones of its base state.
+func (v View) Rules() []Rule {
+	if v.state.base == nil {
+		return v.state.Rules()
+	}
+
+	flat := v.state.Flatten()
+	defer flat.release()
+	return flat.Rules()
+}
+
+// Clone returns a mutable copy of the state.
+func (v View) Clone() *State {
+	return v.state.Clone()
+}
+
+// String returns a string representation of the state.
+func (v View) String() string {
+	return v.state.String()
+}
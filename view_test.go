@@ -0,0 +1,51 @@
+// Copyright (c) Roman Atachiants and contributors. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root
+
+package goap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestView(t *testing.T) {
+	base := StateOf("A", "B=50")
+	state := StateOf("C").WithBase(base)
+	view := ViewOf(state)
+
+	ok, err := view.Match(StateOf("A", "C"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, state.Hash(), view.Hash())
+	assert.Equal(t, state.String(), view.String())
+	assert.Len(t, view.Rules(), 3)
+	assert.Equal(t, float32(50), view.Distance(StateOf("B=100")))
+
+	// The clone is mutable without affecting the viewed state
+	clone := view.Clone()
+	assert.NoError(t, clone.Add("D"))
+	assert.True(t, view.Equals(state))
+	assert.False(t, view.Equals(clone))
+}
+
+func TestPlanView(t *testing.T) {
+	var seen []uint32
+	plan, err := Plan(StateOf("A", "!B", "!C"), StateOf("C"), []Action{
+		&viewAction{testAction: *move("A->B").(*testAction), seen: &seen},
+		move("B->C"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A->B", "B->C"}, planOf(plan))
+	assert.NotEmpty(t, seen)
+}
+
+type viewAction struct {
+	testAction
+	seen *[]uint32
+}
+
+func (a *viewAction) SimulateView(current View) (*State, *State) {
+	*a.seen = append(*a.seen, current.Hash())
+	return a.require, a.outcome
+}